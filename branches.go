@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultReleaseBranches are the release branch patterns of common branching models.
+var defaultReleaseBranches = []string{
+	"release/{major}.{minor}",
+	"release/{major}.x",
+	"hotfix/{major}.{minor}.{patch}",
+	"support/{major}",
+}
+
+// releaseBranch matches release branches and captures the version line of the branch.
+type releaseBranch struct {
+	pattern string
+	regex   *regexp.Regexp // anchored to the whole reference name
+}
+
+// versionLine is the versions a release branch is for: major only, major and minor, or all of three numbers.
+type versionLine struct {
+	Major, Minor, Patch int
+	Parts               int
+}
+
+var (
+	placeholderRegex = regexp.MustCompile("\\{([^{}]*)\\}")
+	placeholders     = []string{"major", "minor", "patch"}
+)
+
+// compileReleaseBranch compiles a pattern of branch name with placeholders, e.g. release/{major}.{minor}.
+// Placeholders must be the leading ones of {major}, {minor} and {patch}, each at most once.
+// The other characters are matched literally, and the pattern matches the whole branch name.
+func compileReleaseBranch(pattern string) (*releaseBranch, error) {
+	seen := map[string]bool{}
+	for _, m := range placeholderRegex.FindAllStringSubmatch(pattern, -1) {
+		name := m[1]
+		if !contains(placeholders, name) {
+			return nil, fmt.Errorf("unknown placeholder {%s} in %s, must be one of {major}, {minor} and {patch}", name, pattern)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("duplicated placeholder {%s} in %s", name, pattern)
+		}
+		seen[name] = true
+	}
+	for i, name := range placeholders {
+		if i == 0 && !seen[name] {
+			return nil, fmt.Errorf("pattern %s must have {major}", pattern)
+		}
+		if i > 0 && seen[name] && !seen[placeholders[i-1]] {
+			return nil, fmt.Errorf("pattern %s has {%s} without {%s}", pattern, name, placeholders[i-1])
+		}
+	}
+
+	expr := "^refs/heads/"
+	last := 0
+	for _, loc := range placeholderRegex.FindAllStringSubmatchIndex(pattern, -1) {
+		expr += regexp.QuoteMeta(pattern[last:loc[0]])
+		expr += fmt.Sprintf("(?P<%s>0|[1-9]\\d*)", pattern[loc[2]:loc[3]])
+		last = loc[1]
+	}
+	expr += regexp.QuoteMeta(pattern[last:]) + "$"
+
+	return &releaseBranch{pattern: pattern, regex: regexp.MustCompile(expr)}, nil
+}
+
+// compileReleaseBranchRegexp compiles a regexp capturing major and minor numbers in order, or by named groups.
+// It is anchored to the whole reference name, which may be omitted.
+func compileReleaseBranchRegexp(expr string) (*releaseBranch, error) {
+	re, err := regexp.Compile("^(?:refs/heads/)?(?:" + strings.TrimSuffix(strings.TrimPrefix(expr, "^"), "$") + ")$")
+	if err != nil {
+		return nil, err
+	}
+	if re.NumSubexp() < 2 {
+		return nil, fmt.Errorf("must capture major and minor numbers")
+	}
+	return &releaseBranch{pattern: expr, regex: re}, nil
+}
+
+// match returns the version line of branch, or nil if it doesn't match.
+func (b *releaseBranch) match(branch string) *versionLine {
+	m := b.regex.FindStringSubmatch(branch)
+	if m == nil {
+		return nil
+	}
+
+	groups := map[string]string{}
+	for i, name := range b.regex.SubexpNames() {
+		if name != "" {
+			groups[name] = m[i]
+		}
+	}
+	// regexps of release_branch capture major and minor numbers in order
+	if _, ok := groups["major"]; !ok {
+		groups["major"], groups["minor"] = m[1], m[2]
+	}
+
+	line := &versionLine{}
+	nums := []*int{&line.Major, &line.Minor, &line.Patch}
+	for i, name := range placeholders {
+		s, ok := groups[name]
+		if !ok {
+			break
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil
+		}
+		*nums[i] = n
+		line.Parts++
+	}
+	return line
+}
+
+// contains tells whether v is of the line. A line of three numbers has the version and the later patches.
+func (l *versionLine) contains(v *VersionTag) bool {
+	switch l.Parts {
+	case 1:
+		return v.Major == l.Major
+	case 2:
+		return v.Major == l.Major && v.Minor == l.Minor
+	default:
+		return v.Major == l.Major && v.Minor == l.Minor && v.Patch >= l.Patch
+	}
+}
+
+// base returns the first version of the line, bumped from when the line has no tags yet.
+func (l *versionLine) base(t *TagTemplate) *VersionTag {
+	return &VersionTag{Tag: t.Prefix, Major: l.Major, Minor: l.Minor, Patch: l.Patch, Suffix: t.Suffix}
+}
+
+// maxBump returns the highest part bumped in the line. The major number is never bumped,
+// and lines of major and minor, e.g. release/1.4, or of three numbers only get patches,
+// since a minor bump would leave the line.
+func (l *versionLine) maxBump() Bump {
+	if l.Parts == 1 {
+		return BumpMinor
+	}
+	return BumpPatch
+}
+
+func contains(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
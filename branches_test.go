@@ -0,0 +1,59 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompileReleaseBranch(t *testing.T) {
+	cases := map[string]map[string]*versionLine{
+		"release/{major}.{minor}": {
+			"refs/heads/release/1.2":              {Major: 1, Minor: 2, Parts: 2},
+			"refs/heads/old-release/1.2":          nil,
+			"refs/heads/release/1.2-wip":          nil,
+			"refs/heads/release/1.x":              nil,
+			"refs/heads/release/01.2":             nil,
+			"refs/tags/release/1.2":               nil,
+			"refs/heads/feature/release/1.2":      nil,
+			"refs/heads/release/1.2/experimental": nil,
+		},
+		"release/{major}.x": {
+			"refs/heads/release/3.x": {Major: 3, Parts: 1},
+			"refs/heads/release/3.y": nil,
+		},
+		"hotfix/{major}.{minor}.{patch}": {
+			"refs/heads/hotfix/1.2.3": {Major: 1, Minor: 2, Patch: 3, Parts: 3},
+			"refs/heads/hotfix/1.2":   nil,
+		},
+		"support/{major}": {
+			"refs/heads/support/2": {Major: 2, Parts: 1},
+		},
+	}
+
+	for pattern, branches := range cases {
+		b, err := compileReleaseBranch(pattern)
+		if !assert.NoError(t, err, pattern) {
+			continue
+		}
+		for branch, line := range branches {
+			assert.Equal(t, line, b.match(branch), "%s matches %s", branch, pattern)
+		}
+	}
+
+	for _, pattern := range []string{"release/{minor}", "release/{major}.{patch}", "release/{major}.{major}", "release/{version}"} {
+		_, err := compileReleaseBranch(pattern)
+		assert.Error(t, err, pattern)
+	}
+}
+
+func TestCompileReleaseBranchRegexp(t *testing.T) {
+	b, err := compileReleaseBranchRegexp("release/(0|[1-9]\\d*)\\.(0|[1-9]\\d*)")
+	assert.NoError(t, err)
+	assert.Equal(t, &versionLine{Major: 1, Minor: 2, Parts: 2}, b.match("refs/heads/release/1.2"))
+	assert.Nil(t, b.match("refs/heads/old-release/1.2-wip"))
+
+	b, err = compileReleaseBranchRegexp("^refs/heads/v(?P<major>\\d+)\\.(?P<minor>\\d+)\\.(?P<patch>\\d+)-fixes$")
+	assert.NoError(t, err)
+	assert.Equal(t, &versionLine{Major: 1, Minor: 2, Patch: 3, Parts: 3}, b.match("refs/heads/v1.2.3-fixes"))
+}
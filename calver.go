@@ -0,0 +1,245 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// CalVerFormat is a layout of calendar versions, such as YYYY.MM.MICRO.
+// See https://calver.org/
+//
+// Up to three segments are mapped to Major, Minor and Patch of VersionTag in order,
+// so versions of the same format are compared by Compare as well.
+type CalVerFormat struct {
+	layout string
+	tokens []string
+	seps   []string // seps[i] is between tokens[i] and tokens[i+1]
+	regex  *regexp.Regexp
+}
+
+// calverTokens are patterns of the segments in a calendar version.
+var calverTokens = map[string]string{
+	"YYYY":  "([1-9]\\d{3})",
+	"YY":    "(0|[1-9]\\d?)",
+	"0Y":    "(\\d{2})",
+	"MM":    "([1-9]|1[0-2])",
+	"0M":    "(0[1-9]|1[0-2])",
+	"WW":    "([1-9]|[1-4]\\d|5[0-3])",
+	"0W":    "(0[1-9]|[1-4]\\d|5[0-3])",
+	"DD":    "([1-9]|[12]\\d|3[01])",
+	"0D":    "(0[1-9]|[12]\\d|3[01])",
+	"MICRO": "(0|[1-9]\\d*)",
+}
+
+var calverLayoutRegex = regexp.MustCompile("^([0-9A-Z]+)(?:([.\\-_])([0-9A-Z]+))?(?:([.\\-_])([0-9A-Z]+))?$")
+
+func ParseCalVerFormat(layout string) (*CalVerFormat, error) {
+	m := calverLayoutRegex.FindStringSubmatch(layout)
+	if m == nil {
+		return nil, fmt.Errorf("invalid calver format: <%s>", layout)
+	}
+
+	f := &CalVerFormat{layout: layout}
+	pattern := ""
+	for i := 1; i < len(m); i += 2 {
+		token := m[i]
+		if token == "" {
+			break
+		}
+
+		if i > 1 {
+			sep := m[i-1]
+			f.seps = append(f.seps, sep)
+			pattern += regexp.QuoteMeta(sep)
+		}
+
+		p, ok := calverTokens[token]
+		if !ok {
+			return nil, fmt.Errorf("invalid calver format: <%s>, unknown segment %s", layout, token)
+		}
+		if token == "MICRO" && i+2 < len(m) && m[i+2] != "" {
+			return nil, fmt.Errorf("invalid calver format: <%s>, MICRO must be the last segment", layout)
+		}
+
+		f.tokens = append(f.tokens, token)
+		pattern += p
+	}
+
+	if f.tokens[0] == "MICRO" {
+		return nil, fmt.Errorf("invalid calver format: <%s>, needs a date segment", layout)
+	}
+
+	f.regex = regexp.MustCompile("^" + pattern + "$")
+	return f, nil
+}
+
+func (f *CalVerFormat) String() string {
+	return f.layout
+}
+
+func (f *CalVerFormat) hasMicro() bool {
+	return f.tokens[len(f.tokens)-1] == "MICRO"
+}
+
+func (f *CalVerFormat) hasWeek() bool {
+	for _, token := range f.tokens {
+		if token == "WW" || token == "0W" {
+			return true
+		}
+	}
+	return false
+}
+
+// Version returns the version of date with micro, which is ignored by formats without MICRO.
+// Week formats use the ISO week and its year, so the first days of January can belong to the last year.
+func (f *CalVerFormat) Version(date time.Time, micro int) *VersionTag {
+	year := date.Year()
+	isoYear, week := date.ISOWeek()
+	if f.hasWeek() {
+		year = isoYear
+	}
+
+	v := &VersionTag{calver: f}
+	for i, token := range f.tokens {
+		var n int
+		switch token {
+		case "YYYY":
+			n = year
+		case "YY", "0Y":
+			n = year % 100
+		case "MM", "0M":
+			n = int(date.Month())
+		case "WW", "0W":
+			n = week
+		case "DD", "0D":
+			n = date.Day()
+		case "MICRO":
+			n = micro
+		}
+		*v.segment(i) = n
+	}
+	return v
+}
+
+// Parse parses a version of this format without tag prefix.
+func (f *CalVerFormat) Parse(str string) (*VersionTag, error) {
+	m := f.regex.FindStringSubmatch(str)
+	if m == nil {
+		return nil, fmt.Errorf("invalid tag format: <%s>, wanted %s", str, f.layout)
+	}
+
+	v := &VersionTag{calver: f}
+	for i := range f.tokens {
+		*v.segment(i), _ = strconv.Atoi(m[i+1])
+	}
+	return v, nil
+}
+
+func (f *CalVerFormat) format(v *VersionTag) string {
+	ret := ""
+	for i, token := range f.tokens {
+		if i > 0 {
+			ret += f.seps[i-1]
+		}
+
+		n := *v.segment(i)
+		switch {
+		case token == "YYYY":
+			ret += fmt.Sprintf("%04d", n)
+		case strings.HasPrefix(token, "0"):
+			ret += fmt.Sprintf("%02d", n)
+		default:
+			ret += strconv.Itoa(n)
+		}
+	}
+	return ret
+}
+
+// sameDate tells whether a and b have the same date segments.
+func (f *CalVerFormat) sameDate(a, b *VersionTag) bool {
+	for i, token := range f.tokens {
+		if token != "MICRO" && *a.segment(i) != *b.segment(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// segment returns the number of i-th segment of calendar version.
+func (v *VersionTag) segment(i int) *int {
+	return []*int{&v.Major, &v.Minor, &v.Patch}[i]
+}
+
+// calverStrategy versions by date in the configured timezone, e.g. 2026.10.0, 2026.10.1, 2026.11.0.
+// The micro counter increases among versions of the same date, and resets when the date changes.
+type calverStrategy struct{}
+
+func (calverStrategy) Name() string {
+	return "calver"
+}
+
+func (calverStrategy) Next(ctx *StrategyContext) (*NextVersion, error) {
+	template := ctx.Template
+	format := ctx.Config.calverFormat
+
+	tags, err := ctx.Repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	version := format.Version(ctx.Now, 0)
+	version.Tag = template.Prefix
+	version.Suffix = template.Suffix
+
+	var previous, sameDate *VersionTag
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		body, ok := template.trim(ref.Name().Short())
+		if !ok {
+			return nil
+		}
+
+		current, err := format.Parse(body)
+		if err != nil {
+			return nil
+		}
+		current.ref = ref
+		current.Tag = template.Prefix
+		current.Suffix = template.Suffix
+
+		if previous == nil || Compare(current, previous) > 0 {
+			previous = current
+		}
+
+		if format.sameDate(current, version) && (sameDate == nil || Compare(current, sameDate) > 0) {
+			sameDate = current
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reason := "new date " + version.String()
+	if sameDate != nil {
+		if !format.hasMicro() {
+			return nil, &TagExistsError{Tag: sameDate.String(), Reason: fmt.Sprintf("the version of %s, and calver format %s has no MICRO", ctx.Now.Format("2006-01-02"), format)}
+		}
+
+		version = bumped(sameDate, BumpNone)
+		*version.segment(len(format.tokens) - 1)++
+		reason = "next micro of " + sameDate.String()
+	}
+
+	return &NextVersion{
+		Version:  version,
+		Previous: previous,
+		Part:     bumpBetween(previous, version),
+		Reason:   reason,
+	}, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalVerFormat(t *testing.T) {
+	date := time.Date(2026, time.January, 2, 10, 0, 0, 0, time.UTC)
+
+	for layout, expected := range map[string]string{
+		"YYYY.MM.MICRO": "2026.1.3",
+		"YY.0M.MICRO":   "26.01.3",
+		"YYYY.0W":       "2026.01",
+		"0Y.0M.0D":      "26.01.02",
+		"YYYY-MM-DD":    "2026-1-2",
+	} {
+		f, err := ParseCalVerFormat(layout)
+		assert.NoError(t, err, layout)
+
+		v := f.Version(date, 3)
+		assert.Equal(t, expected, v.String(), layout)
+
+		parsed, err := f.Parse(expected)
+		assert.NoError(t, err, layout)
+		assert.Equal(t, 0, Compare(v, parsed), layout)
+		assert.Equal(t, expected, parsed.String(), layout)
+	}
+
+	// ISO week 53 of 2020
+	f, _ := ParseCalVerFormat("YYYY.0W")
+	assert.Equal(t, "2020.53", f.Version(time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC), 0).String())
+
+	for _, layout := range []string{"", "YYYY.MICRO.MM", "MICRO", "YYYY.MM.DD.MICRO", "YYYY/MM", "YYYY.XX"} {
+		_, err := ParseCalVerFormat(layout)
+		assert.Error(t, err, layout)
+	}
+
+	f, _ = ParseCalVerFormat("YY.0M.MICRO")
+	_, err := f.Parse("26.1.0")
+	assert.Error(t, err)
+}
+
+func TestCalverStrategy(t *testing.T) {
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "calver"
+		cfg.WithoutV = true
+		cfg.CalVerFormat = "YY.0M.MICRO"
+	})
+
+	r := newTestRepo(t, "main")
+	h := r.commit("initial")
+
+	ctx, err := newStrategyContext(r.Repository, cfg, nil)
+	assert.NoError(t, err)
+	ctx.Now = time.Date(2026, time.October, 17, 10, 0, 0, 0, cfg.location)
+
+	next, err := calverStrategy{}.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "26.10.0", next.Version.String())
+	assert.Nil(t, next.Previous)
+
+	r.tag("26.09.4", h)
+	r.tag("26.10.0", h)
+	r.tag("26.10.1", h)
+	r.tag("v1.2.3", h)
+
+	next, err = calverStrategy{}.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "26.10.2", next.Version.String())
+	assert.Equal(t, BumpPatch, next.Part)
+	assert.Equal(t, "26.10.1", previousTag(next.Previous))
+
+	ctx.Now = time.Date(2026, time.November, 1, 10, 0, 0, 0, cfg.location)
+	next, err = calverStrategy{}.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "26.11.0", next.Version.String())
+	assert.Equal(t, BumpMinor, next.Part)
+
+	cfg.calverFormat, _ = ParseCalVerFormat("0Y.0M")
+	ctx.Now = time.Date(2026, time.October, 17, 10, 0, 0, 0, cfg.location)
+	r.tag("26.10", h)
+	_, err = calverStrategy{}.Next(ctx)
+	assert.IsType(t, &TagExistsError{}, err)
+}
+
+func TestConfig_Timezone(t *testing.T) {
+	cfg := testConfig(t, func(cfg *Config) { cfg.Timezone = "UTC" })
+	assert.Equal(t, "UTC", cfg.now().Location().String())
+
+	cfg = DefaultConfig()
+	cfg.Timezone = "Mars/Olympus"
+	err := cfg.Validate()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "timezone", err.(*ConfigError).Key)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/openpgp"
+)
+
+// changelogHeader starts a new CHANGELOG.md in the layout of Keep a Changelog.
+// See https://keepachangelog.com/en/1.1.0/
+const changelogHeader = `# Changelog
+
+All notable changes to this project will be documented in this file.
+
+The format is based on [Keep a Changelog](https://keepachangelog.com/en/1.1.0/).
+`
+
+// changelogGroups are the groups of changes in a section, in order.
+var changelogGroups = []string{"Breaking changes", "Features", "Fixes", "Other"}
+
+// renderChangelogSection renders the section of version with commits grouped by Conventional Commits type.
+func renderChangelogSection(version *VersionTag, date time.Time, commits []*object.Commit) string {
+	return fmt.Sprintf("## %s - %s\n\n", version.String(), date.Format("2006-01-02")) + renderReleaseNotes(commits)
+}
+
+// renderReleaseNotes renders commits grouped by Conventional Commits type in Markdown.
+func renderReleaseNotes(commits []*object.Commit) string {
+	if len(commits) == 0 {
+		return "Nothing new, just for tagging.\n"
+	}
+
+	entries := map[string][]string{}
+	for _, c := range commits {
+		group, entry := changelogEntry(c)
+		entries[group] = append(entries[group], entry)
+	}
+
+	var groups []string
+	for _, group := range changelogGroups {
+		if len(entries[group]) == 0 {
+			continue
+		}
+
+		notes := "### " + group + "\n\n"
+		for _, entry := range entries[group] {
+			notes += "- " + entry + "\n"
+		}
+		groups = append(groups, notes)
+	}
+	return strings.Join(groups, "\n")
+}
+
+func changelogEntry(c *object.Commit) (string, string) {
+	hash := c.Hash.String()[:7]
+
+	cc, ok := ParseConventionalCommit(c.Message)
+	if !ok {
+		header := strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0]
+		return "Other", fmt.Sprintf("%s (%s)", header, hash)
+	}
+
+	entry := fmt.Sprintf("%s (%s)", cc.Subject, hash)
+	if cc.Scope != "" {
+		entry = fmt.Sprintf("**%s:** %s", cc.Scope, entry)
+	}
+
+	switch {
+	case cc.Breaking:
+		return "Breaking changes", entry
+	case cc.Type == "feat":
+		return "Features", entry
+	case cc.Type == "fix":
+		return "Fixes", entry
+	default:
+		return "Other", entry
+	}
+}
+
+// prependChangelog inserts sections before the latest released one, keeping the header and Unreleased section on top.
+func prependChangelog(changelog string, sections []string) string {
+	if strings.TrimSpace(changelog) == "" {
+		changelog = changelogHeader
+	}
+
+	lines := strings.SplitAfter(changelog, "\n")
+	at := len(lines)
+	for i, line := range lines {
+		if strings.HasPrefix(line, "## ") && !strings.Contains(strings.ToLower(line), "unreleased") {
+			at = i
+			break
+		}
+	}
+
+	head := strings.Join(lines[:at], "")
+	if !strings.HasSuffix(head, "\n") {
+		head += "\n"
+	}
+	if !strings.HasSuffix(head, "\n\n") {
+		head += "\n"
+	}
+
+	return head + strings.Join(sections, "\n") + "\n" + strings.Join(lines[at:], "")
+}
+
+// changelogSections renders a section for each planned tag.
+func changelogSections(plans []*plannedTag, date time.Time) []string {
+	var sections []string
+	for _, p := range plans {
+		sections = append(sections, renderChangelogSection(p.Next.Version, date, p.Commits))
+	}
+	return sections
+}
+
+// changelogCommitPrefix starts the message of changelog commits, followed by the new tags.
+const changelogCommitPrefix = "chore(release): "
+
+// isChangelogCommitOf tells whether h is a changelog commit made on parent. It is false if h is missing.
+func isChangelogCommitOf(r *git.Repository, h, parent plumbing.Hash) (bool, error) {
+	c, err := r.CommitObject(h)
+	if err == plumbing.ErrObjectNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return len(c.ParentHashes) == 1 && c.ParentHashes[0] == parent && strings.HasPrefix(c.Message, changelogCommitPrefix), nil
+}
+
+// commitChangelog prepends sections of planned tags to the changelog file, and commits it on the branch checked out.
+// The commit is signed with signKey if not nil. It returns the refspec to push the branch together with tags.
+func commitChangelog(r *git.Repository, cfg *Config, plans []*plannedTag, signKey *openpgp.Entity) (config.RefSpec, error) {
+	head, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+
+	if !head.Name().IsBranch() {
+		return "", fmt.Errorf("changelog must be committed on branch, got %s", head.Name())
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(w.Filesystem.Root(), cfg.ChangelogFile)
+
+	b, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	changelog := prependChangelog(string(b), changelogSections(plans, cfg.now()))
+	if err := os.WriteFile(path, []byte(changelog), 0644); err != nil {
+		return "", err
+	}
+
+	if _, err := w.Add(cfg.ChangelogFile); err != nil {
+		return "", err
+	}
+
+	var tags []string
+	for _, p := range plans {
+		tags = append(tags, p.Next.Version.String())
+	}
+
+	signature := &object.Signature{Name: cfg.TaggerName, Email: cfg.TaggerEmail, When: cfg.now()}
+	h, err := w.Commit(changelogCommitPrefix+strings.Join(tags, ", "), &git.CommitOptions{
+		Author:    signature,
+		Committer: signature,
+		SignKey:   signKey,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	Info("Committed %s: %s", cfg.ChangelogFile, h.String())
+
+	return config.RefSpec(fmt.Sprintf("%s:%s", head.Name(), head.Name())), nil
+}
+
+// resetChangelogCommit moves HEAD back to base to drop the changelog commit, and restores the changelog file of base
+// in the index and worktree. The other changes, e.g. made by earlier steps of workflow, are kept.
+func resetChangelogCommit(r *git.Repository, cfg *Config, base plumbing.Hash) error {
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+
+	if err := w.Reset(&git.ResetOptions{Commit: base, Mode: git.SoftReset}); err != nil {
+		return err
+	}
+
+	c, err := r.CommitObject(base)
+	if err != nil {
+		return err
+	}
+
+	f, err := c.File(filepath.ToSlash(cfg.ChangelogFile))
+	if err == object.ErrFileNotFound {
+		_, err = w.Remove(cfg.ChangelogFile)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	content, err := f.Contents()
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(w.Filesystem.Root(), cfg.ChangelogFile), []byte(content), 0644); err != nil {
+		return err
+	}
+
+	_, err = w.Add(cfg.ChangelogFile)
+	return err
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderChangelogSection(t *testing.T) {
+	r := newTestRepo(t, "main")
+	r.commit("initial")
+	r.commit("fix(api): handle nil")
+	r.commit("feat: add endpoint")
+	r.commit("feat!: drop v1")
+	r.commit("Merge branch 'foo'")
+
+	commits, err := r.Log(&git.LogOptions{})
+	assert.NoError(t, err)
+
+	var all []*object.Commit
+	assert.NoError(t, commits.ForEach(func(c *object.Commit) error {
+		all = append(all, c)
+		return nil
+	}))
+
+	version := &VersionTag{Tag: "v", Major: 2}
+	section := renderChangelogSection(version, time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC), all[:4])
+
+	short := func(i int) string { return all[i].Hash.String()[:7] }
+	assert.Equal(t, "## v2.0.0 - 2026-10-17\n"+
+		"\n### Breaking changes\n\n- drop v1 ("+short(1)+")\n"+
+		"\n### Features\n\n- add endpoint ("+short(2)+")\n"+
+		"\n### Fixes\n\n- **api:** handle nil ("+short(3)+")\n"+
+		"\n### Other\n\n- Merge branch 'foo' ("+short(0)+")\n", section)
+
+	assert.Equal(t, "## v2.0.0 - 2026-10-17\n\nNothing new, just for tagging.\n",
+		renderChangelogSection(version, time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC), nil))
+}
+
+func TestPrependChangelog(t *testing.T) {
+	changelog := prependChangelog("", []string{"## v1.0.0 - 2026-10-17\n"})
+	assert.Equal(t, changelogHeader+"\n## v1.0.0 - 2026-10-17\n\n", changelog)
+
+	changelog = prependChangelog("# Changelog\n\n## [Unreleased]\n\n- wip\n\n## v1.0.0 - 2026-10-17\n\n- first\n",
+		[]string{"## v1.1.0 - 2026-10-18\n", "## api/v0.1.0 - 2026-10-18\n"})
+	assert.Equal(t, "# Changelog\n\n## [Unreleased]\n\n- wip\n\n"+
+		"## v1.1.0 - 2026-10-18\n\n## api/v0.1.0 - 2026-10-18\n\n"+
+		"## v1.0.0 - 2026-10-17\n\n- first\n", changelog)
+}
+
+func TestCommitChangelog(t *testing.T) {
+	cfg := testConfig(t, func(cfg *Config) { cfg.Changelog = true })
+
+	r := newTestRepo(t, "main")
+	h := r.commit("initial")
+	c, err := r.CommitObject(h)
+	assert.NoError(t, err)
+
+	plans := []*plannedTag{{
+		Next:    &NextVersion{Version: &VersionTag{Tag: "v", Major: 1}},
+		Commits: []*object.Commit{c},
+	}}
+
+	refSpec, err := commitChangelog(r.Repository, cfg, plans, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, config.RefSpec("refs/heads/main:refs/heads/main"), refSpec)
+
+	head, err := getHeadCommit(r.Repository)
+	assert.NoError(t, err)
+	assert.Equal(t, "chore(release): v1.0.0", head.Message)
+	assert.Equal(t, h, head.ParentHashes[0])
+
+	w, err := r.Worktree()
+	assert.NoError(t, err)
+	b, err := os.ReadFile(filepath.Join(w.Filesystem.Root(), "CHANGELOG.md"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "## v1.0.0 - ")
+	assert.Contains(t, string(b), "- initial ("+h.String()[:7]+")")
+
+	status, err := w.Status()
+	assert.NoError(t, err)
+	assert.True(t, status.IsClean())
+}
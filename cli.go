@@ -0,0 +1,490 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// command is a subcommand of command line, to use the action locally.
+type command struct {
+	Args  string // usage of arguments after flags
+	Help  string
+	Flags func(fs *flag.FlagSet, c *commandContext) // flags of the command besides the common ones, optional
+	Run   func(c *commandContext, args []string) error
+}
+
+var commands = map[string]*command{
+	"current": {
+		Help: "Print the latest version tag, of each component with components",
+		Run:  runCurrent,
+	},
+	"next": {
+		Help: "Print the next version which tag would create, or the version tag HEAD already has",
+		Flags: func(fs *flag.FlagSet, c *commandContext) {
+			fs.StringVar(&c.prerelease, "prerelease", "", "channel of pre-release, e.g. alpha, beta or rc, defaults to prerelease")
+		},
+		Run: runNext,
+	},
+	"notes": {
+		Args: "[<from>[..<to>]]",
+		Help: "Print release notes of commits after from until to, which default to the latest version tag and HEAD",
+		Flags: func(fs *flag.FlagSet, c *commandContext) {
+			fs.StringVar(&c.component, "component", "", "name of component to filter commits by its paths")
+		},
+		Run: runNotes,
+	},
+	"query": {
+		Args: "<constraint>",
+		Help: "Print the latest version tag satisfying constraint, e.g. ~1.4, ^1.2.0, '>=1.0.0 <2.0.0' or 1.x, and its commit",
+		Run:  runQuery,
+	},
+	"tag": {
+		Help: "Create and push the next version tag, as the action does",
+		Flags: func(fs *flag.FlagSet, c *commandContext) {
+			fs.BoolVar(&c.dryRun, "dry-run", false, "report the next tag without creating or pushing it, defaults to dry_run")
+			fs.StringVar(&c.prerelease, "prerelease", "", "channel of pre-release, e.g. alpha, beta or rc, defaults to prerelease")
+		},
+		Run: runTag,
+	},
+	"validate": {
+		Args: "<version>",
+		Help: "Check version is a semantic version with optional prefix",
+		Run:  runValidate,
+	},
+}
+
+// commandContext holds the common flags of commands, and where to print results.
+type commandContext struct {
+	name       string
+	repoPath   string
+	json       bool
+	fetch      bool
+	dryRun     bool
+	component  string
+	prerelease string
+	passed     map[string]bool // names of flags passed, which override the configuration
+	out        io.Writer
+}
+
+// runCommand runs the command of args, printing results to stdout and logs to stderr, and returns the exit code.
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	name := args[0]
+	cmd, ok := commands[name]
+	if !ok {
+		if name == "help" || name == "-h" || name == "--help" {
+			printUsage(stdout)
+			return 0
+		}
+		fmt.Fprintf(stderr, "unknown command: %s\n\n", name)
+		printUsage(stderr)
+		return ExitConfig
+	}
+
+	c := &commandContext{name: name, passed: map[string]bool{}, out: stdout}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: github-tag-action %s [flags] %s\n\n%s\n\nFlags:\n", name, cmd.Args, cmd.Help)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&c.repoPath, "repo", "", "path of repository, defaults to repo_path")
+	fs.BoolVar(&c.json, "json", false, "print results in JSON")
+	fs.BoolVar(&c.fetch, "fetch", false, "fetch remote tags and deepen shallow history first, defaults to fetch")
+	if cmd.Flags != nil {
+		cmd.Flags(fs, c)
+	}
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return ExitConfig
+	}
+	fs.Visit(func(f *flag.Flag) { c.passed[f.Name] = true })
+
+	// results are printed to stdout, so logs are written to stderr
+	defer func(l *Logger) { logger = l }(logger)
+	logger = NewLogger(stderr, logger.debug)
+
+	if err := cmd.Run(c, fs.Args()); err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+		return exitCode(err)
+	}
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "Usage: github-tag-action [<command> [flags] [args]]")
+	fmt.Fprintln(w, "\nWithout command, runs as GitHub Action configured by inputs and environment variables.")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].Help)
+	}
+	fmt.Fprintln(w, "\nRun 'github-tag-action <command> -h' for flags of command.")
+}
+
+// config loads the configuration as the action does, overridden by flags passed.
+func (c *commandContext) config() (*Config, error) {
+	return loadConfig(func(cfg *Config) {
+		if c.repoPath != "" {
+			cfg.RepoPath = c.repoPath
+		}
+		if c.passed["fetch"] {
+			cfg.Fetch = c.fetch
+		}
+		if c.passed["dry-run"] {
+			cfg.DryRun = c.dryRun
+		}
+		if c.prerelease != "" {
+			cfg.Prerelease = c.prerelease
+		}
+	})
+}
+
+// open loads the configuration and opens the repository, fetching tags with -fetch.
+func (c *commandContext) open() (*Config, *git.Repository, error) {
+	cfg, err := c.config()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, err := openRepository(cfg.RepoPath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cfg.Fetch {
+		if err := fetchTags(r, cfg); err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch tags: %w", err)
+		}
+	}
+	return cfg, r, nil
+}
+
+// print prints lines, or value in JSON with -json.
+func (c *commandContext) print(lines []string, value interface{}) error {
+	if c.json {
+		enc := json.NewEncoder(c.out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(c.out, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printVersions prints tags of versions, or versions in JSON: an object, or an array with components.
+func (c *commandContext) printVersions(cfg *Config, versions []*versionJSON) error {
+	var lines []string
+	for _, v := range versions {
+		lines = append(lines, v.Tag)
+	}
+
+	if len(cfg.Components) == 0 && len(versions) == 1 {
+		return c.print(lines, versions[0])
+	}
+	return c.print(lines, versions)
+}
+
+// versionJSON is the machine-readable form of version, named after the outputs of action.
+type versionJSON struct {
+	Component   string `json:"component,omitempty"`
+	NewTag      string `json:"new_tag,omitempty"`
+	Tag         string `json:"tag"`
+	Part        string `json:"part,omitempty"`
+	Major       int    `json:"major"`
+	Minor       int    `json:"minor"`
+	Patch       int    `json:"patch"`
+	Prerelease  string `json:"prerelease"`
+	Build       string `json:"build"`
+	PreviousTag string `json:"previous_tag,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	Strategy    string `json:"strategy,omitempty"`
+	Commit      string `json:"commit,omitempty"`
+}
+
+func newVersionJSON(version *VersionTag, commit plumbing.Hash) *versionJSON {
+	v := &versionJSON{
+		Tag:        version.String(),
+		Major:      version.Major,
+		Minor:      version.Minor,
+		Patch:      version.Patch,
+		Prerelease: version.Pre,
+		Build:      version.Build,
+	}
+	if !commit.IsZero() {
+		v.Commit = commit.String()
+	}
+	return v
+}
+
+func runCurrent(c *commandContext, args []string) error {
+	if len(args) > 0 {
+		return &UsageError{Command: c.name, Reason: "no arguments are expected"}
+	}
+
+	cfg, r, err := c.open()
+	if err != nil {
+		return err
+	}
+
+	targets := []*Component{nil}
+	if len(cfg.Components) > 0 {
+		targets = nil
+		for i := range cfg.Components {
+			targets = append(targets, &cfg.Components[i])
+		}
+	}
+
+	var versions []*versionJSON
+	for _, comp := range targets {
+		latest, err := latestVersionTag(r, cfg, comp)
+		if err != nil {
+			return err
+		}
+		if latest == nil {
+			if comp == nil {
+				return fmt.Errorf("no version tag of %s", cfg.template(nil))
+			}
+			continue
+		}
+
+		h, err := tagCommit(r, latest.ref)
+		if err != nil {
+			return err
+		}
+
+		v := newVersionJSON(latest, h)
+		if comp != nil {
+			v.Component = comp.Name
+		}
+		versions = append(versions, v)
+	}
+
+	return c.printVersions(cfg, versions)
+}
+
+// latestVersionTag returns the version tag of the highest precedence of repository, or of component if not nil.
+func latestVersionTag(r *git.Repository, cfg *Config, component *Component) (*VersionTag, error) {
+	ctx, err := newStrategyContext(r, cfg, component)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.latest(func(*VersionTag) bool { return true }), nil
+}
+
+func runNext(c *commandContext, args []string) error {
+	if len(args) > 0 {
+		return &UsageError{Command: c.name, Reason: "no arguments are expected"}
+	}
+
+	cfg, r, err := c.open()
+	if err != nil {
+		return err
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return err
+	}
+
+	plans, err := computePlans(r, cfg)
+	if err != nil {
+		return err
+	}
+
+	var versions []*versionJSON
+	for _, p := range plans {
+		var v *versionJSON
+		if p.Existing != nil {
+			v = newVersionJSON(p.Existing, p.Tagged)
+			v.Part = BumpNone.String()
+		} else {
+			v = newVersionJSON(p.Next.Version, head.Hash())
+			v.NewTag = v.Tag
+			v.Part = p.Next.Part.String()
+			v.PreviousTag = previousTag(p.Next.Previous)
+			v.Reason = p.Next.Reason
+			v.Strategy = p.Next.Strategy
+		}
+		if p.Component != nil {
+			v.Component = p.Component.Name
+		}
+		versions = append(versions, v)
+	}
+
+	return c.printVersions(cfg, versions)
+}
+
+// notesJSON is the machine-readable form of release notes.
+type notesJSON struct {
+	From    string       `json:"from,omitempty"` // empty since the first commit
+	To      string       `json:"to"`
+	Notes   string       `json:"notes"`
+	Commits []commitJSON `json:"commits"`
+}
+
+type commitJSON struct {
+	Commit  string `json:"commit"`
+	Subject string `json:"subject"`
+}
+
+func runNotes(c *commandContext, args []string) error {
+	if len(args) > 1 {
+		return &UsageError{Command: c.name, Reason: "only a range of commits is expected"}
+	}
+
+	cfg, r, err := c.open()
+	if err != nil {
+		return err
+	}
+
+	var comp *Component
+	if c.component != "" {
+		for i := range cfg.Components {
+			if cfg.Components[i].Name == c.component {
+				comp = &cfg.Components[i]
+			}
+		}
+		if comp == nil {
+			return &UsageError{Command: c.name, Reason: fmt.Sprintf("no component named %s", c.component)}
+		}
+	}
+
+	from, to := "", "HEAD"
+	if len(args) == 1 {
+		from = args[0]
+		if i := strings.Index(args[0], ".."); i >= 0 {
+			from, to = args[0][:i], args[0][i+2:]
+		}
+	}
+	if from == "" {
+		latest, err := latestVersionTag(r, cfg, comp)
+		if err != nil {
+			return err
+		}
+		if latest != nil {
+			from = latest.ref.Name().Short()
+		}
+	}
+
+	var fromHash plumbing.Hash
+	if from != "" {
+		h, err := r.ResolveRevision(plumbing.Revision(from))
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s: %w", from, err)
+		}
+		fromHash = *h
+	}
+
+	toHash, err := r.ResolveRevision(plumbing.Revision(to))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", to, err)
+	}
+
+	commits, err := commitsBetween(r, fromHash, *toHash)
+	if err != nil {
+		return err
+	}
+	if comp != nil {
+		commits, err = filterCommitsByPaths(commits, comp.Paths)
+		if err != nil {
+			return err
+		}
+	}
+
+	notes := renderReleaseNotes(commits)
+	result := &notesJSON{From: from, To: to, Notes: notes, Commits: []commitJSON{}}
+	for _, commit := range commits {
+		subject := strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0]
+		result.Commits = append(result.Commits, commitJSON{Commit: commit.Hash.String(), Subject: subject})
+	}
+
+	return c.print([]string{strings.TrimSuffix(notes, "\n")}, result)
+}
+
+func runQuery(c *commandContext, args []string) error {
+	if len(args) == 0 {
+		return &UsageError{Command: c.name, Reason: "a constraint is expected"}
+	}
+
+	// comparators may be given as separate arguments without quotes
+	constraint, err := ParseConstraint(strings.Join(args, " "))
+	if err != nil {
+		return &UsageError{Command: c.name, Reason: err.Error()}
+	}
+
+	cfg, r, err := c.open()
+	if err != nil {
+		return err
+	}
+
+	tags, err := versionTags(r, cfg.template(nil))
+	if err != nil {
+		return err
+	}
+
+	latest := constraint.Latest(tags)
+	if latest == nil {
+		return &NoMatchingTagError{Constraint: constraint.String()}
+	}
+
+	h, err := tagCommit(r, latest.ref)
+	if err != nil {
+		return err
+	}
+
+	return c.print([]string{latest.String() + " " + h.String()}, newVersionJSON(latest, h))
+}
+
+func runTag(c *commandContext, args []string) error {
+	if len(args) > 0 {
+		return &UsageError{Command: c.name, Reason: "no arguments are expected"}
+	}
+
+	cfg, err := c.config()
+	if err != nil {
+		return err
+	}
+
+	outputs := NewOutputs()
+	if err := tagRepository(cfg, outputs); err != nil {
+		return err
+	}
+
+	if c.json {
+		return c.print(nil, outputs.values)
+	}
+	return outputs.Write(c.out)
+}
+
+func runValidate(c *commandContext, args []string) error {
+	if len(args) != 1 {
+		return &UsageError{Command: c.name, Reason: "a version is expected"}
+	}
+
+	version, err := VersionFromString(args[0])
+	if err != nil {
+		return &InvalidVersionError{Version: args[0]}
+	}
+
+	return c.print([]string{version.String()}, newVersionJSON(version, plumbing.ZeroHash))
+}
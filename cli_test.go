@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// runTestCommand runs command on the repository, and returns the exit code and stdout.
+// Tags are not fetched unless fetch is configured, as test repositories have no remote.
+func runTestCommand(t *testing.T, r *testRepo, args ...string) (int, string) {
+	if _, ok := os.LookupEnv("INPUT_FETCH"); !ok {
+		setEnv(t, "INPUT_FETCH", "false")
+	}
+
+	w, err := r.Worktree()
+	assert.NoError(t, err)
+
+	var stdout, stderr bytes.Buffer
+	args = append([]string{args[0], "-repo", w.Filesystem.Root()}, args[1:]...)
+	code := runCommand(args, &stdout, &stderr)
+	if code != 0 {
+		t.Log(stderr.String())
+	}
+	return code, stdout.String()
+}
+
+func TestCommand_Current(t *testing.T) {
+	r := newTestRepo(t, "main")
+	r.annotatedTag("v1.0.0", r.commit("feat: first"))
+	second := r.commit("fix: second")
+	r.tag("v1.0.1", second)
+	r.commit("feat: third")
+
+	code, out := runTestCommand(t, r, "current")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "v1.0.1\n", out)
+
+	code, out = runTestCommand(t, r, "current", "-json")
+	assert.Equal(t, 0, code)
+	var v versionJSON
+	assert.NoError(t, json.Unmarshal([]byte(out), &v))
+	assert.Equal(t, versionJSON{Tag: "v1.0.1", Major: 1, Patch: 1, Commit: second.String()}, v)
+
+	code, _ = runTestCommand(t, newTestRepo(t, "main"), "current")
+	assert.Equal(t, ExitFailure, code)
+}
+
+func TestCommand_Next(t *testing.T) {
+	setEnv(t, "INPUT_STRATEGY", "trunk")
+
+	r := newTestRepo(t, "main")
+	r.tag("v1.0.0", r.commit("chore: first"))
+	head := r.commit("feat: second")
+
+	code, out := runTestCommand(t, r, "next")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "v1.1.0\n", out)
+
+	code, out = runTestCommand(t, r, "next", "-json")
+	assert.Equal(t, 0, code)
+	var v versionJSON
+	assert.NoError(t, json.Unmarshal([]byte(out), &v))
+	assert.Equal(t, "v1.1.0", v.NewTag)
+	assert.Equal(t, "minor", v.Part)
+	assert.Equal(t, "v1.0.0", v.PreviousTag)
+	assert.Equal(t, "trunk", v.Strategy)
+	assert.Equal(t, head.String(), v.Commit)
+
+	code, out = runTestCommand(t, r, "next", "-prerelease", "beta")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "v1.1.0-beta.1\n", out)
+
+	// nothing is created
+	_, err := r.Tag("v1.1.0")
+	assert.Error(t, err)
+
+	r.tag("v1.1.0", head)
+	code, out = runTestCommand(t, r, "next", "-json")
+	assert.Equal(t, 0, code)
+	v = versionJSON{}
+	assert.NoError(t, json.Unmarshal([]byte(out), &v))
+	assert.Equal(t, "", v.NewTag)
+	assert.Equal(t, "v1.1.0", v.Tag)
+	assert.Equal(t, "none", v.Part)
+}
+
+func TestCommand_Notes(t *testing.T) {
+	r := newTestRepo(t, "main")
+	r.commit("chore: first")
+	r.annotatedTag("v1.0.0", r.commit("feat: second"))
+	fix := r.commit("fix: third")
+
+	code, out := runTestCommand(t, r, "notes")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "### Fixes\n\n- third ("+fix.String()[:7]+")\n", out)
+
+	code, out = runTestCommand(t, r, "notes", "-json", "v1.0.0~1..v1.0.0")
+	assert.Equal(t, 0, code)
+	var notes notesJSON
+	assert.NoError(t, json.Unmarshal([]byte(out), &notes))
+	assert.Equal(t, "v1.0.0~1", notes.From)
+	assert.Equal(t, "v1.0.0", notes.To)
+	assert.Contains(t, notes.Notes, "### Features\n\n- second (")
+	assert.Len(t, notes.Commits, 1)
+
+	code, out = runTestCommand(t, r, "notes", "-json", "v1.0.0")
+	assert.Equal(t, 0, code)
+	notes = notesJSON{}
+	assert.NoError(t, json.Unmarshal([]byte(out), &notes))
+	assert.Equal(t, []commitJSON{{Commit: fix.String(), Subject: "fix: third"}}, notes.Commits)
+
+	code, _ = runTestCommand(t, r, "notes", "-component", "api")
+	assert.Equal(t, ExitConfig, code)
+}
+
+func TestCommand_Tag(t *testing.T) {
+	setEnv(t, "INPUT_STRATEGY", "trunk")
+
+	r := newTestRepo(t, "main")
+	r.tag("v1.0.0", r.commit("chore: first"))
+	r.commit("fix: second")
+
+	code, out := runTestCommand(t, r, "tag", "-dry-run", "-json")
+	assert.Equal(t, 0, code)
+	var outputs map[string]string
+	assert.NoError(t, json.Unmarshal([]byte(out), &outputs))
+	assert.Equal(t, "v1.0.1", outputs["new_tag"])
+	assert.Equal(t, "patch", outputs["part"])
+
+	_, err := r.Tag("v1.0.1")
+	assert.Error(t, err)
+}
+
+func TestCommand_ConfiguredFlags(t *testing.T) {
+	setEnv(t, "INPUT_STRATEGY", "trunk")
+	setEnv(t, "INPUT_FETCH", "true")
+	setEnv(t, "INPUT_DRY_RUN", "true")
+
+	r := newTestRepo(t, "main")
+	remoteDir := newTestRemote(t, r)
+	pushTagByAnotherRun(t, r, remoteDir, "v1.0.1")
+	r.commit("fix: mine")
+
+	// flags passed override the configuration
+	code, out := runTestCommand(t, r, "current", "-fetch=false")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "v1.0.0\n", out)
+
+	// flags not passed keep it
+	code, out = runTestCommand(t, r, "current")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "v1.0.1\n", out)
+
+	code, _ = runTestCommand(t, r, "tag")
+	assert.Equal(t, 0, code)
+	_, err := r.Tag("v1.0.2")
+	assert.Equal(t, git.ErrTagNotFound, err)
+
+	code, _ = runTestCommand(t, r, "tag", "-dry-run=false")
+	assert.Equal(t, 0, code)
+	head, err := r.Head()
+	assert.NoError(t, err)
+	assert.Equal(t, head.Hash(), remoteTagCommit(t, remoteDir, "v1.0.2"))
+}
+
+func TestCommand_Validate(t *testing.T) {
+	var stdout bytes.Buffer
+	assert.Equal(t, 0, runCommand([]string{"validate", "-json", "v1.2.3-rc.1+build.5"}, &stdout, &bytes.Buffer{}))
+	var v versionJSON
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &v))
+	assert.Equal(t, versionJSON{Tag: "v1.2.3-rc.1+build.5", Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1", Build: "build.5"}, v)
+
+	var stderr bytes.Buffer
+	assert.Equal(t, ExitInvalidVersion, runCommand([]string{"validate", "v1.2"}, &bytes.Buffer{}, &stderr))
+	assert.Contains(t, stderr.String(), "invalid version: v1.2")
+
+	assert.Equal(t, ExitConfig, runCommand([]string{"validate"}, &bytes.Buffer{}, &bytes.Buffer{}))
+	assert.Equal(t, ExitConfig, runCommand([]string{"unknown"}, &bytes.Buffer{}, &bytes.Buffer{}))
+	assert.Equal(t, 0, runCommand([]string{"help"}, &bytes.Buffer{}, &bytes.Buffer{}))
+}
+
+func TestCommand_Query(t *testing.T) {
+	r := newTestRepo(t, "main")
+	first := r.commit("feat: first")
+	r.annotatedTag("v1.4.1", first)
+	second := r.commit("fix: second")
+	r.tag("v1.4.2", second)
+	r.tag("v1.5.0-rc.1", r.commit("feat: third"))
+
+	code, out := runTestCommand(t, r, "query", "~1.4")
+	assert.Equal(t, 0, code)
+	assert.Equal(t, "v1.4.2 "+second.String()+"\n", out)
+
+	code, out = runTestCommand(t, r, "query", "-json", ">=1.0.0", "<1.4.2")
+	assert.Equal(t, 0, code)
+	var v versionJSON
+	assert.NoError(t, json.Unmarshal([]byte(out), &v))
+	assert.Equal(t, "v1.4.1", v.Tag)
+	assert.Equal(t, first.String(), v.Commit)
+
+	code, _ = runTestCommand(t, r, "query", "^2")
+	assert.Equal(t, ExitNoMatchingTag, code)
+
+	code, _ = runTestCommand(t, r, "query", "~>1.4")
+	assert.Equal(t, ExitConfig, code)
+}
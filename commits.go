@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Bump is the part of a version which is increased for a new release.
+type Bump int
+
+const (
+	BumpNone Bump = iota
+	BumpPrerelease
+	BumpPatch
+	BumpMinor
+	BumpMajor
+)
+
+func (b Bump) String() string {
+	switch b {
+	case BumpPrerelease:
+		return "prerelease"
+	case BumpPatch:
+		return "patch"
+	case BumpMinor:
+		return "minor"
+	case BumpMajor:
+		return "major"
+	default:
+		return "none"
+	}
+}
+
+// ConventionalCommit is a commit message parsed by the Conventional Commits 1.0.0 rules.
+// See https://www.conventionalcommits.org/en/v1.0.0/
+type ConventionalCommit struct {
+	Type     string
+	Scope    string
+	Subject  string
+	Body     string
+	Breaking bool
+}
+
+var (
+	conventionalHeaderRegex = regexp.MustCompile("^([a-zA-Z]+)(?:\\(([^()\\r\\n]*)\\))?(!)?: +(.+)$")
+	breakingFooterRegex     = regexp.MustCompile("(?m)^BREAKING[ -]CHANGE: ")
+)
+
+// ParseConventionalCommit parses header and footers of a commit message.
+// It returns false when the header doesn't follow Conventional Commits.
+func ParseConventionalCommit(message string) (*ConventionalCommit, bool) {
+	message = strings.TrimSpace(message)
+	header, body := message, ""
+	if i := strings.Index(message, "\n"); i >= 0 {
+		header, body = message[:i], strings.TrimSpace(message[i+1:])
+	}
+
+	matches := conventionalHeaderRegex.FindStringSubmatch(strings.TrimSpace(header))
+	if matches == nil {
+		return nil, false
+	}
+
+	return &ConventionalCommit{
+		Type:     strings.ToLower(matches[1]),
+		Scope:    matches[2],
+		Subject:  matches[4],
+		Body:     body,
+		Breaking: matches[3] == "!" || breakingFooterRegex.MatchString(body),
+	}, true
+}
+
+// Bump returns the part of version which this commit requires to increase.
+func (c *ConventionalCommit) Bump() Bump {
+	switch {
+	case c.Breaking:
+		return BumpMajor
+	case c.Type == "feat":
+		return BumpMinor
+	case c.Type == "fix" || c.Type == "perf":
+		return BumpPatch
+	default:
+		return BumpNone
+	}
+}
+
+// analyzeCommits returns the highest bump required by the given commits, and the first commit requiring it as reason.
+// Commits which don't follow Conventional Commits are ignored.
+func analyzeCommits(commits []*object.Commit) (Bump, string) {
+	bump, reason := BumpNone, ""
+	for _, c := range commits {
+		cc, ok := ParseConventionalCommit(c.Message)
+		if !ok {
+			continue
+		}
+
+		if b := cc.Bump(); b > bump {
+			bump = b
+			reason = fmt.Sprintf("%s (%s)", strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0], c.Hash.String()[:7])
+		}
+	}
+	return bump, reason
+}
+
+// commitsSince walks the log from HEAD back to the commit of prevTag, which is excluded,
+// and returns the commits changing files under paths. No paths means whole repository.
+// There are no commits without prevTag, as the first version has nothing to compare with.
+func commitsSince(r *git.Repository, prevTag *VersionTag, paths []string) ([]*object.Commit, error) {
+	if prevTag == nil {
+		return nil, nil
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		Warning("Failed to get head reference: %s", err.Error())
+		return nil, err
+	}
+
+	// the commit may be missing in shallow repository until the history is deepened
+	h, err := tagCommit(r, prevTag.ref)
+	if err != nil {
+		Warning("Failed to get latest tag: %s", err.Error())
+		return nil, err
+	}
+
+	commits, err := commitsBetween(r, h, head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	return filterCommitsByPaths(commits, paths)
+}
+
+// commitsBetween walks the log from to back to from, and returns the commits in the history of to
+// which aren't in the history of from, like git log from..to, newest first along first parents.
+// The walk stops at ancestors of from, and at shallow commits, whose parents are missing until deepened.
+func commitsBetween(r *git.Repository, from, to plumbing.Hash) ([]*object.Commit, error) {
+	excluded, _, err := ancestors(r, from)
+	if err != nil {
+		Warning("Failed to walk history of %s: %s", from.String(), err.Error())
+		return nil, err
+	}
+
+	stop, err := shallowCommits(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []*object.Commit
+	seen := map[plumbing.Hash]bool{}
+	stack := []plumbing.Hash{to}
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if seen[h] || excluded[h] {
+			continue
+		}
+		seen[h] = true
+
+		commit, err := r.CommitObject(h)
+		if err != nil {
+			Warning("Failed to get commit %s: %s", h.String(), err.Error())
+			return nil, err
+		}
+		commits = append(commits, commit)
+
+		if stop[h] {
+			continue
+		}
+		// the first parent is walked first
+		for i := len(commit.ParentHashes) - 1; i >= 0; i-- {
+			stack = append(stack, commit.ParentHashes[i])
+		}
+	}
+
+	return commits, nil
+}
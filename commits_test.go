@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConventionalCommit(t *testing.T) {
+	c, ok := ParseConventionalCommit("feat(api): add tags endpoint\n\nSome details.\n")
+	assert.True(t, ok)
+	assert.Equal(t, "feat", c.Type)
+	assert.Equal(t, "api", c.Scope)
+	assert.Equal(t, "add tags endpoint", c.Subject)
+	assert.Equal(t, "Some details.", c.Body)
+	assert.False(t, c.Breaking)
+	assert.Equal(t, BumpMinor, c.Bump())
+
+	c, ok = ParseConventionalCommit("fix!: drop old flag")
+	assert.True(t, ok)
+	assert.True(t, c.Breaking)
+	assert.Equal(t, BumpMajor, c.Bump())
+
+	c, ok = ParseConventionalCommit("refactor: rename\n\nBREAKING CHANGE: config key renamed")
+	assert.True(t, ok)
+	assert.True(t, c.Breaking)
+	assert.Equal(t, BumpMajor, c.Bump())
+
+	c, ok = ParseConventionalCommit("fix: typo\n\nReviewed-by: someone\nBREAKING-CHANGE: yes")
+	assert.True(t, ok)
+	assert.Equal(t, BumpMajor, c.Bump())
+
+	c, ok = ParseConventionalCommit("docs: readme")
+	assert.True(t, ok)
+	assert.Equal(t, BumpNone, c.Bump())
+
+	_, ok = ParseConventionalCommit("Merge pull request #1 from foo/bar")
+	assert.False(t, ok)
+}
+
+func TestAnalyzeCommits(t *testing.T) {
+	r := newTestRepo(t, "master")
+	first := r.commit("initial")
+	r.tag("v1.0.0", first)
+	r.commit("fix: one")
+	second := r.commit("feat: two\n\ndetails")
+	r.commit("feat: three")
+	r.commit("Merge branch 'foo'")
+
+	previous, err := VersionFromString("v1.0.0")
+	assert.NoError(t, err)
+	previous.ref, err = r.Tag("v1.0.0")
+	assert.NoError(t, err)
+
+	commits, err := commitsSince(r.Repository, previous, nil)
+	assert.NoError(t, err)
+	assert.Len(t, commits, 4)
+
+	bump, reason := analyzeCommits(commits)
+	assert.Equal(t, BumpMinor, bump)
+	assert.Equal(t, "feat: three ("+commits[1].Hash.String()[:7]+")", reason)
+	assert.NotEqual(t, second, commits[1].Hash)
+
+	commits, err = commitsSince(r.Repository, nil, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, commits)
+}
+
+func TestCommitsBetween(t *testing.T) {
+	r := newTestRepo(t, "main")
+	first := r.commit("initial")
+	side := r.commit("feat: side")
+	fix := r.commitOn("fix: main", first)
+	merge := r.commitOn("Merge branch 'side'", fix, side)
+
+	hashes := func(commits []*object.Commit) []plumbing.Hash {
+		var hs []plumbing.Hash
+		for _, c := range commits {
+			hs = append(hs, c.Hash)
+		}
+		return hs
+	}
+
+	// commits merged from another branch are walked, ancestors of from are not
+	commits, err := commitsBetween(r.Repository, fix, merge)
+	assert.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{merge, side}, hashes(commits))
+
+	commits, err = commitsBetween(r.Repository, side, merge)
+	assert.NoError(t, err)
+	assert.Equal(t, []plumbing.Hash{merge, fix}, hashes(commits))
+
+	// from out of the history of to
+	commits, err = commitsBetween(r.Repository, merge, fix)
+	assert.NoError(t, err)
+	assert.Empty(t, commits)
+
+	// missing commits are errors, not the end of history
+	_, err = commitsBetween(r.Repository, first, plumbing.NewHash("0123456789abcdef0123456789abcdef01234567"))
+	assert.Equal(t, plumbing.ErrObjectNotFound, err)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// Component is a deployable part of monorepo, which is versioned independently with its own tags such as api/v1.4.2.
+// Only commits changing files under Paths are counted for its version and annotation.
+type Component struct {
+	Name      string   `yaml:"name"`
+	TagPrefix string   `yaml:"tag_prefix"` // defaults to name and slash followed by tag_prefix of config, e.g. api/v
+	Paths     []string `yaml:"paths"`
+}
+
+var componentNameRegex = regexp.MustCompile("^[a-zA-Z0-9][a-zA-Z0-9_.-]*$")
+
+// filterCommitsByPaths returns commits changing files under any of paths. No paths means whole repository.
+func filterCommitsByPaths(commits []*object.Commit, paths []string) ([]*object.Commit, error) {
+	if len(paths) == 0 {
+		return commits, nil
+	}
+
+	var filtered []*object.Commit
+	for _, c := range commits {
+		ok, err := touchesPaths(c, paths)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, nil
+}
+
+// touchesPaths tells whether commit changes any file under paths, compared with its first parent.
+// The parent of a shallow commit is missing until the history is deepened, so its changes are unknown.
+// It counts as changing paths then, so the component is planned and ensureHistory deepens to its previous tag.
+func touchesPaths(c *object.Commit, paths []string) (bool, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return false, err
+	}
+
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err == plumbing.ErrObjectNotFound {
+			return true, nil
+		}
+		if err != nil {
+			return false, err
+		}
+
+		parentTree, err = parent.Tree()
+		if err != nil {
+			return false, err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return false, err
+	}
+
+	for _, change := range changes {
+		for _, name := range []string{change.From.Name, change.To.Name} {
+			if name != "" && matchPaths(name, paths) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// matchPaths tells whether file is one of paths or under one of them.
+func matchPaths(file string, paths []string) bool {
+	for _, p := range paths {
+		p = strings.Trim(path.Clean(p), "/")
+		if p == "." || p == "" || file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPaths(t *testing.T) {
+	assert.True(t, matchPaths("api/main.go", []string{"api"}))
+	assert.True(t, matchPaths("api/main.go", []string{"worker/", "api/"}))
+	assert.True(t, matchPaths("go.mod", []string{"go.mod"}))
+	assert.True(t, matchPaths("go.mod", []string{"."}))
+	assert.False(t, matchPaths("apiv2/main.go", []string{"api"}))
+	assert.False(t, matchPaths("worker/main.go", []string{"api"}))
+}
+
+func TestPlanTags_Components(t *testing.T) {
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.Components = []Component{
+			{Name: "api", Paths: []string{"api"}},
+			{Name: "worker", Paths: []string{"worker"}},
+			{Name: "web", TagPrefix: "web-", Paths: []string{"web"}},
+		}
+	})
+	assert.Equal(t, "api/v", cfg.Components[0].TagPrefix)
+
+	r := newTestRepo(t, "main")
+	h := r.commitFile("README.md", "initial")
+	r.tag("api/v1.4.1", h)
+	r.tag("worker/v0.9.0", h)
+	r.tag("v5.0.0", h)
+	r.commitFile("api/main.go", "fix: api")
+	r.commitFile("worker/main.go", "feat: worker")
+	r.commitFile("api/handler.go", "feat!: api")
+	r.commitFile("docs/index.md", "docs: only docs")
+
+	plans, err := planTags(r.Repository, cfg)
+	assert.NoError(t, err)
+	if !assert.Len(t, plans, 3) {
+		return
+	}
+
+	assert.Equal(t, "api", plans[0].Component.Name)
+	assert.Equal(t, "api/v2.0.0", plans[0].Next.Version.String())
+	assert.Equal(t, "api/v1.4.1", previousTag(plans[0].Next.Previous))
+	assert.Equal(t, "* feat!: api* fix: api", plans[0].Message)
+
+	assert.Equal(t, "worker/v0.10.0", plans[1].Next.Version.String())
+	assert.Equal(t, "* feat: worker", plans[1].Message)
+
+	// first version of component
+	assert.Equal(t, "web-0.0.1", plans[2].Next.Version.String())
+
+	r.tag("api/v2.0.0", r.commitFile("worker/other.go", "fix: worker"))
+	r.tag("web-0.0.1", r.commit("chore: nothing"))
+
+	plans, err = planTags(r.Repository, cfg)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 2) {
+		assert.Equal(t, "worker/v0.10.0", plans[0].Next.Version.String())
+
+		// HEAD is already tagged
+		assert.Equal(t, "web", plans[1].Component.Name)
+		assert.Equal(t, "web-0.0.1", plans[1].Existing.String())
+		assert.Nil(t, plans[1].Next)
+	}
+}
+
+func TestConfig_Components(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Components = []Component{{Name: "api"}}
+	err := cfg.Validate()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "components", err.(*ConfigError).Key)
+	}
+
+	cfg = DefaultConfig()
+	cfg.Components = []Component{{Name: "api", Paths: []string{"a"}}, {Name: "api", Paths: []string{"b"}}}
+	assert.Error(t, cfg.Validate())
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // the container image may have no zoneinfo
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config is the configuration of action.
+//
+// Each key is read from, in order of precedence:
+//   - the action input, e.g. INPUT_TAG_PREFIX
+//   - the environment variable, e.g. TAG_PREFIX
+//   - the config file in repository, .github/tag-action.yml by default
+//   - the default value of DefaultConfig
+type Config struct {
+	RepoPath   string `yaml:"repo_path"`
+	ConfigFile string `yaml:"config_file"`
+	RepoToken  string `yaml:"repo_token"`
+
+	// Remote is the name of remote to push tags, or the name of RemoteURL if set.
+	Remote    string `yaml:"remote"`
+	RemoteURL string `yaml:"remote_url"`
+
+	// Fetch fetches remote tags, and deepens shallow history to the previous tags before computing versions.
+	Fetch bool `yaml:"fetch"`
+
+	// PushAttempts bounds pushes of tags, which are retried with recomputed versions when another run pushed them first.
+	PushAttempts int `yaml:"push_attempts"`
+
+	// FloatingTags are the parts of version, major and minor, whose tags like v1 and v1.4 follow the highest release.
+	FloatingTags []string `yaml:"floating_tags"`
+
+	// Auth is one of token, ssh-key, ssh-agent and none, chosen by the given credentials if empty.
+	// SSH host keys are verified by SSHKnownHosts, lines of known_hosts, and SSHKnownHostsFile,
+	// or by the default known_hosts files if both are empty.
+	Auth              string `yaml:"auth"`
+	SSHUser           string `yaml:"ssh_user"`
+	SSHPrivateKey     string `yaml:"ssh_private_key"`
+	SSHPassphrase     string `yaml:"ssh_passphrase"`
+	SSHKnownHosts     string `yaml:"ssh_known_hosts"`
+	SSHKnownHostsFile string `yaml:"ssh_known_hosts_file"`
+
+	// TagTemplate is the form of tag names with {prefix}, {version} and {suffix}, replaced by TagPrefix and TagSuffix.
+	TagTemplate string `yaml:"tag_template"`
+	TagPrefix   string `yaml:"tag_prefix"`
+	TagSuffix   string `yaml:"tag_suffix"`
+	WithoutV    bool   `yaml:"without_v"` // shorthand of empty tag_prefix
+
+	TaggerName  string `yaml:"tagger_name"`
+	TaggerEmail string `yaml:"tagger_email"`
+
+	// Sign signs tags with the armored OpenPGP private key, decrypted with passphrase if encrypted.
+	Sign          bool   `yaml:"sign"`
+	GPGPrivateKey string `yaml:"gpg_private_key"`
+	GPGPassphrase string `yaml:"gpg_passphrase"`
+
+	// Timezone is the IANA name of location for dates of tagger and calendar versions.
+	Timezone     string `yaml:"timezone"`
+	CalVerFormat string `yaml:"calver_format"`
+
+	DryRun bool `yaml:"dry_run"` // compute and report the next tag, without creating or pushing it
+	Force  bool `yaml:"force"`   // create a new tag even if HEAD already has a version tag
+
+	// Changelog prepends a section of new version to ChangelogFile, and commits it before tagging.
+	Changelog     bool   `yaml:"changelog"`
+	ChangelogFile string `yaml:"changelog_file"`
+
+	// Release creates GitHub release of new tag with release notes.
+	Release          bool   `yaml:"release"`
+	ReleaseDraft     bool   `yaml:"release_draft"`
+	GithubAPIURL     string `yaml:"github_api_url"`
+	GithubRepository string `yaml:"github_repository"` // owner/repo
+	GithubServerURL  string `yaml:"github_server_url"` // to link tags in job summary
+
+	// Strategy forces a strategy, instead of selecting it by Branches.
+	Strategy string           `yaml:"strategy"`
+	Branches []BranchStrategy `yaml:"branches"`
+
+	// ReleaseBranches are patterns of release branches with placeholders of version line, e.g. release/{major}.{minor}.
+	// ReleaseBranch is the former regexp capturing major and minor numbers, tried first if set.
+	ReleaseBranches []string `yaml:"release_branches"`
+	ReleaseBranch   string   `yaml:"release_branch"`
+	DevelopBranch   string   `yaml:"develop_branch"`
+
+	// Prerelease is the channel of pre-releases to tag, e.g. beta for 1.5.0-beta.1, one of PrereleaseChannels in order.
+	Prerelease         string   `yaml:"prerelease"`
+	PrereleaseChannels []string `yaml:"prerelease_channels"`
+
+	// Components are versioned independently in monorepo, only in config file.
+	Components []Component `yaml:"components"`
+
+	releaseBranches    []*releaseBranch
+	developBranchRegex *regexp.Regexp
+	location           *time.Location
+	calverFormat       *CalVerFormat
+	tagTemplate        *TagTemplate
+}
+
+// BranchStrategy maps branches matching Pattern to Strategy.
+type BranchStrategy struct {
+	Pattern  string `yaml:"pattern"`
+	Strategy string `yaml:"strategy"`
+
+	regex *regexp.Regexp
+}
+
+// ConfigError tells which key of configuration is invalid.
+type ConfigError struct {
+	Key    string
+	Reason string
+
+	// File and Line point to the config file, if the error is found in it.
+	File string
+	Line int
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("invalid config <%s>: %s", e.Key, e.Reason)
+}
+
+var (
+	githubRepositoryRegex = regexp.MustCompile("^[\\w.-]+/[\\w.-]+$")
+	yamlLineRegex         = regexp.MustCompile("line (\\d+)")
+)
+
+func DefaultConfig() *Config {
+	return &Config{
+		RepoPath:           "./",
+		ConfigFile:         ".github/tag-action.yml",
+		Remote:             "origin",
+		Fetch:              true,
+		PushAttempts:       3,
+		SSHUser:            "git",
+		TagTemplate:        "{prefix}{version}{suffix}",
+		TagPrefix:          "v",
+		TaggerName:         "whiteblock",
+		TaggerEmail:        "developer@whiteblock.co",
+		Timezone:           "Asia/Seoul",
+		CalVerFormat:       "YYYY.MM.MICRO",
+		ChangelogFile:      "CHANGELOG.md",
+		GithubAPIURL:       "https://api.github.com",
+		GithubServerURL:    "https://github.com",
+		ReleaseBranches:    append([]string{}, defaultReleaseBranches...),
+		DevelopBranch:      "^refs/heads/develop$",
+		PrereleaseChannels: append([]string{}, defaultPrereleaseChannels...),
+	}
+}
+
+// LoadConfig loads configuration from action inputs, environment variables and the config file, then validates it.
+func LoadConfig() (*Config, error) {
+	return loadConfig(nil)
+}
+
+// loadConfig is LoadConfig with override applied over the environment if not nil, e.g. for flags of command line.
+func loadConfig(override func(*Config)) (*Config, error) {
+	cfg := DefaultConfig()
+
+	// repo_path and config_file are needed first to find the config file
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if override != nil {
+		override(cfg)
+	}
+
+	path := cfg.ConfigFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cfg.RepoPath, path)
+	}
+
+	if err := cfg.loadFile(path); err != nil {
+		return nil, err
+	}
+
+	// environment takes precedence over the config file
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if override != nil {
+		override(cfg)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// loadFile loads the config file in yaml. Missing file is not an error, as the file is optional.
+func (c *Config) loadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := yaml.UnmarshalStrict(b, c); err != nil {
+		cfgErr := &ConfigError{Key: "config_file", Reason: fmt.Sprintf("%s: %s", path, err), File: path}
+		if m := yamlLineRegex.FindStringSubmatch(err.Error()); m != nil {
+			cfgErr.Line, _ = strconv.Atoi(m[1])
+		}
+		return cfgErr
+	}
+
+	Debug("Loaded config file: %s", path)
+	return nil
+}
+
+// loadEnv overrides keys which are set in action inputs or environment variables.
+// Empty values are regarded as unset.
+func (c *Config) loadEnv() error {
+	v := reflect.ValueOf(c).Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+
+		value, ok := lookupEnv(key)
+		if !ok {
+			continue
+		}
+
+		if err := setConfigField(v.Field(i), value); err != nil {
+			return &ConfigError{Key: key, Reason: err.Error()}
+		}
+	}
+
+	return nil
+}
+
+func lookupEnv(key string) (string, bool) {
+	name := strings.ToUpper(key)
+
+	if value := os.Getenv("INPUT_" + name); value != "" {
+		return value, true
+	}
+
+	if value := os.Getenv(name); value != "" {
+		return value, true
+	}
+
+	return "", false
+}
+
+func setConfigField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("not a boolean: %s", value)
+		}
+		field.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("not an integer: %s", value)
+		}
+		field.SetInt(int64(n))
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("only supported in config file")
+		}
+		var items []string
+		for _, item := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("only supported in config file")
+	}
+	return nil
+}
+
+// Validate checks values and prepares the derived ones, such as compiled branch patterns.
+func (c *Config) Validate() error {
+	if c.RepoPath == "" {
+		return &ConfigError{Key: "repo_path", Reason: "must not be empty"}
+	}
+
+	if !tagAffixRegex.MatchString(c.TagPrefix) {
+		return &ConfigError{Key: "tag_prefix", Reason: fmt.Sprintf("must be valid in tag names, got %s", c.TagPrefix)}
+	}
+
+	if !tagAffixRegex.MatchString(c.TagSuffix) {
+		return &ConfigError{Key: "tag_suffix", Reason: fmt.Sprintf("must be valid in tag names, got %s", c.TagSuffix)}
+	}
+
+	t, err := ParseTagTemplate(c.TagTemplate, c.tagPrefix(), c.TagSuffix)
+	if err != nil {
+		return &ConfigError{Key: "tag_template", Reason: err.Error()}
+	}
+	c.tagTemplate = t
+
+	if c.Remote == "" {
+		return &ConfigError{Key: "remote", Reason: "must not be empty"}
+	}
+
+	if c.PushAttempts < 1 {
+		return &ConfigError{Key: "push_attempts", Reason: fmt.Sprintf("must be at least 1, got %d", c.PushAttempts)}
+	}
+
+	for _, part := range c.FloatingTags {
+		if !contains(floatingParts, part) {
+			return &ConfigError{Key: "floating_tags", Reason: fmt.Sprintf("must be some of %v, got %s", floatingParts, part)}
+		}
+	}
+
+	if c.Auth == "" {
+		c.Auth = c.defaultAuth()
+	}
+	switch c.Auth {
+	case AuthToken:
+		if c.RepoToken == "" {
+			return &ConfigError{Key: "repo_token", Reason: "must not be empty for token auth"}
+		}
+	case AuthSSHKey:
+		if strings.TrimSpace(c.SSHPrivateKey) == "" {
+			return &ConfigError{Key: "ssh_private_key", Reason: "must not be empty for ssh-key auth"}
+		}
+	case AuthSSHAgent, AuthNone:
+	default:
+		return &ConfigError{Key: "auth", Reason: fmt.Sprintf("must be one of %v, got %s", authMethods, c.Auth)}
+	}
+
+	if (c.Auth == AuthSSHKey || c.Auth == AuthSSHAgent) && c.SSHUser == "" {
+		return &ConfigError{Key: "ssh_user", Reason: "must not be empty for ssh auth"}
+	}
+
+	if c.TaggerName == "" {
+		return &ConfigError{Key: "tagger_name", Reason: "must not be empty"}
+	}
+
+	if c.TaggerEmail == "" {
+		return &ConfigError{Key: "tagger_email", Reason: "must not be empty"}
+	}
+
+	if c.Sign && strings.TrimSpace(c.GPGPrivateKey) == "" {
+		return &ConfigError{Key: "gpg_private_key", Reason: "must not be empty to sign"}
+	}
+
+	if c.Changelog && c.ChangelogFile == "" {
+		return &ConfigError{Key: "changelog_file", Reason: "must not be empty"}
+	}
+
+	if c.Release {
+		if !githubRepositoryRegex.MatchString(c.GithubRepository) {
+			return &ConfigError{Key: "github_repository", Reason: fmt.Sprintf("must be owner/repo, got %s", c.GithubRepository)}
+		}
+		if c.RepoToken == "" {
+			return &ConfigError{Key: "repo_token", Reason: "must not be empty to create release"}
+		}
+	}
+
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return &ConfigError{Key: "timezone", Reason: err.Error()}
+	}
+	c.location = loc
+
+	f, err := ParseCalVerFormat(c.CalVerFormat)
+	if err != nil {
+		return &ConfigError{Key: "calver_format", Reason: err.Error()}
+	}
+	c.calverFormat = f
+
+	c.releaseBranches = nil
+	if c.ReleaseBranch != "" {
+		b, err := compileReleaseBranchRegexp(c.ReleaseBranch)
+		if err != nil {
+			return &ConfigError{Key: "release_branch", Reason: err.Error()}
+		}
+		c.releaseBranches = append(c.releaseBranches, b)
+	}
+	for _, pattern := range c.ReleaseBranches {
+		b, err := compileReleaseBranch(pattern)
+		if err != nil {
+			return &ConfigError{Key: "release_branches", Reason: err.Error()}
+		}
+		c.releaseBranches = append(c.releaseBranches, b)
+	}
+
+	re, err := regexp.Compile(c.DevelopBranch)
+	if err != nil {
+		return &ConfigError{Key: "develop_branch", Reason: err.Error()}
+	}
+	c.developBranchRegex = re
+
+	for i, channel := range c.PrereleaseChannels {
+		if !channelRegex.MatchString(channel) {
+			return &ConfigError{Key: "prerelease_channels", Reason: fmt.Sprintf("must be non-numeric identifiers of pre-release, got %s", channel)}
+		}
+		if indexOf(c.PrereleaseChannels, channel) != i {
+			return &ConfigError{Key: "prerelease_channels", Reason: fmt.Sprintf("duplicated channel %s", channel)}
+		}
+	}
+	if c.Prerelease != "" && !contains(c.PrereleaseChannels, c.Prerelease) {
+		return &ConfigError{Key: "prerelease", Reason: fmt.Sprintf("must be one of %v, got %s", c.PrereleaseChannels, c.Prerelease)}
+	}
+
+	if _, ok := strategies[c.Strategy]; c.Strategy != "" && !ok {
+		return &ConfigError{Key: "strategy", Reason: fmt.Sprintf("must be one of %v, got %s", strategyNames(), c.Strategy)}
+	}
+
+	for i := range c.Branches {
+		b := &c.Branches[i]
+
+		if _, ok := strategies[b.Strategy]; !ok {
+			return &ConfigError{Key: "branches", Reason: fmt.Sprintf("strategy must be one of %v, got %s", strategyNames(), b.Strategy)}
+		}
+
+		re, err := regexp.Compile(b.Pattern)
+		if err != nil {
+			return &ConfigError{Key: "branches", Reason: err.Error()}
+		}
+		b.regex = re
+	}
+
+	names := map[string]bool{}
+	for i := range c.Components {
+		comp := &c.Components[i]
+
+		if !componentNameRegex.MatchString(comp.Name) {
+			return &ConfigError{Key: "components", Reason: fmt.Sprintf("name must match %s, got %s", componentNameRegex.String(), comp.Name)}
+		}
+		if names[comp.Name] {
+			return &ConfigError{Key: "components", Reason: fmt.Sprintf("duplicated name %s", comp.Name)}
+		}
+		names[comp.Name] = true
+
+		if len(comp.Paths) == 0 {
+			return &ConfigError{Key: "components", Reason: fmt.Sprintf("paths of %s must not be empty", comp.Name)}
+		}
+
+		if comp.TagPrefix == "" {
+			comp.TagPrefix = comp.Name + "/" + c.tagTemplate.Prefix
+		}
+		if !tagAffixRegex.MatchString(comp.TagPrefix) {
+			return &ConfigError{Key: "components", Reason: fmt.Sprintf("tag_prefix of %s must be valid in tag names, got %s", comp.Name, comp.TagPrefix)}
+		}
+	}
+
+	return nil
+}
+
+// branchStrategies returns the configured branch mapping followed by the default one:
+// release branches to release-branch strategy and develop_branch to build-number strategy.
+func (c *Config) branchStrategies() []BranchStrategy {
+	strategies := append([]BranchStrategy{}, c.Branches...)
+	for _, b := range c.releaseBranches {
+		strategies = append(strategies, BranchStrategy{Pattern: b.pattern, Strategy: "release-branch", regex: b.regex})
+	}
+	return append(strategies, BranchStrategy{Pattern: c.DevelopBranch, Strategy: "build-number", regex: c.developBranchRegex})
+}
+
+// now returns the current time in the configured timezone.
+func (c *Config) now() time.Time {
+	return time.Now().In(c.location)
+}
+
+// tagPrefix is the prefix of version tags, which is empty with without_v.
+func (c *Config) tagPrefix() string {
+	if c.WithoutV {
+		return ""
+	}
+	return c.TagPrefix
+}
+
+// template returns the template of version tags of repository, or of component if not nil.
+// Components have their own prefixes, in place of the prefix of template.
+func (c *Config) template(component *Component) *TagTemplate {
+	if component == nil {
+		return c.tagTemplate
+	}
+	return &TagTemplate{Prefix: component.TagPrefix, Suffix: c.tagTemplate.Suffix}
+}
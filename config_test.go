@@ -0,0 +1,138 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setEnv(t *testing.T, name, value string) {
+	prev, ok := os.LookupEnv(name)
+	assert.NoError(t, os.Setenv(name, value))
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(name, prev)
+		} else {
+			os.Unsetenv(name)
+		}
+	})
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".github"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".github", "tag-action.yml"), []byte(
+		"tag_prefix: release\ntagger_name: bot\ntagger_email: bot@example.com\n",
+	), 0644))
+
+	setEnv(t, "INPUT_REPO_PATH", dir)
+	setEnv(t, "TAGGER_NAME", "env-bot")
+	setEnv(t, "INPUT_TAGGER_NAME", "input-bot")
+	setEnv(t, "REPO_TOKEN", "secret")
+
+	cfg, err := LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, dir, cfg.RepoPath)
+	assert.Equal(t, "release", cfg.tagPrefix())
+	assert.Equal(t, "input-bot", cfg.TaggerName)
+	assert.Equal(t, "bot@example.com", cfg.TaggerEmail)
+	assert.Equal(t, "secret", cfg.RepoToken)
+
+	setEnv(t, "WITHOUT_V", "true")
+	cfg, err = LoadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.tagPrefix())
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	setEnv(t, "INPUT_REPO_PATH", t.TempDir())
+
+	setEnv(t, "WITHOUT_V", "maybe")
+	_, err := LoadConfig()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "without_v", err.(*ConfigError).Key)
+	}
+	os.Unsetenv("WITHOUT_V")
+
+	setEnv(t, "INPUT_RELEASE_BRANCH", "release/(\\d+)")
+	_, err = LoadConfig()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "release_branch", err.(*ConfigError).Key)
+	}
+	os.Unsetenv("INPUT_RELEASE_BRANCH")
+
+	setEnv(t, "INPUT_RELEASE_BRANCHES", "release/{major}.{minor}, release/{minor}")
+	_, err = LoadConfig()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "release_branches", err.(*ConfigError).Key)
+	}
+	os.Unsetenv("INPUT_RELEASE_BRANCHES")
+
+	setEnv(t, "INPUT_TAG_TEMPLATE", "{prefix}")
+	_, err = LoadConfig()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "tag_template", err.(*ConfigError).Key)
+	}
+	os.Unsetenv("INPUT_TAG_TEMPLATE")
+
+	setEnv(t, "INPUT_FLOATING_TAGS", "major, patch")
+	_, err = LoadConfig()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "floating_tags", err.(*ConfigError).Key)
+	}
+	os.Unsetenv("INPUT_FLOATING_TAGS")
+
+	setEnv(t, "INPUT_PRERELEASE", "preview")
+	_, err = LoadConfig()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "prerelease", err.(*ConfigError).Key)
+	}
+	os.Unsetenv("INPUT_PRERELEASE")
+
+	setEnv(t, "INPUT_PRERELEASE_CHANNELS", "alpha, 1")
+	_, err = LoadConfig()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "prerelease_channels", err.(*ConfigError).Key)
+	}
+	os.Unsetenv("INPUT_PRERELEASE_CHANNELS")
+
+	setEnv(t, "INPUT_TAG_PREFIX", "release v")
+	_, err = LoadConfig()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "tag_prefix", err.(*ConfigError).Key)
+	}
+}
+
+func TestLoadConfig_UnknownKey(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".github"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".github", "tag-action.yml"), []byte("tag_prefx: v\n"), 0644))
+	setEnv(t, "INPUT_REPO_PATH", dir)
+
+	_, err := LoadConfig()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "config_file", err.(*ConfigError).Key)
+		assert.Contains(t, err.Error(), "tag_prefx")
+	}
+}
+
+func TestConfig_Release(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Release = true
+	cfg.RepoToken = "secret"
+	err := cfg.Validate()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "github_repository", err.(*ConfigError).Key)
+	}
+
+	cfg.GithubRepository = "whiteblockco/github-tag-action"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.RepoToken = ""
+	err = cfg.Validate()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "repo_token", err.(*ConfigError).Key)
+	}
+}
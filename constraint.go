@@ -0,0 +1,258 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Constraint is a range of versions, e.g. "~1.4", "^1.2.0", ">=1.0.0 <2.0.0" or "1.x".
+// Comparators separated by spaces or commas must all be satisfied, and ranges separated by "||" are alternatives.
+//
+// As in npm, a pre-release satisfies a range only if a comparator of the range has a pre-release
+// on the same major, minor and patch numbers, so "<2.0.0" excludes pre-releases like v1.9.0-rc.1.
+// See https://github.com/npm/node-semver#ranges
+type Constraint struct {
+	str    string
+	ranges [][]comparator
+}
+
+// comparator is a primitive comparison with version, one of <, <=, >, >= and =.
+// pre tells the version was written with a pre-release, which allows pre-releases of the same numbers.
+type comparator struct {
+	op      string
+	version *VersionTag
+	pre     bool
+}
+
+// partialVersion is a version which may have wildcards, e.g. "1.x" or "1.4". Only the first n numbers are given.
+type partialVersion struct {
+	major, minor, patch int
+	n                   int
+	pre                 string
+}
+
+var (
+	constraintOperatorRegex = regexp.MustCompile("(<=|>=|<|>|=|~|\\^)\\s+")
+	comparatorRegex         = regexp.MustCompile("^(<=|>=|<|>|=|~|\\^)?v?(0|[1-9]\\d*|[xX*])(?:\\.(0|[1-9]\\d*|[xX*]))?(?:\\.(0|[1-9]\\d*|[xX*]))?(?:-((?:0|[1-9]\\d*|\\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\\.(?:0|[1-9]\\d*|\\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\\+[0-9a-zA-Z-]+(?:\\.[0-9a-zA-Z-]+)*)?$")
+)
+
+// ParseConstraint parses the range of versions. Versions may have "v" prefix, and build-metadata is ignored.
+func ParseConstraint(str string) (*Constraint, error) {
+	c := &Constraint{str: str}
+
+	for _, rangeStr := range strings.Split(str, "||") {
+		// allow spaces after operators, e.g. ">= 1.0.0"
+		rangeStr = constraintOperatorRegex.ReplaceAllString(rangeStr, "$1")
+
+		fields := strings.FieldsFunc(rangeStr, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("invalid constraint <%s>: empty range", str)
+		}
+
+		var comps []comparator
+		for _, field := range fields {
+			parsed, err := parseComparator(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint <%s>: %w", str, err)
+			}
+			comps = append(comps, parsed...)
+		}
+		c.ranges = append(c.ranges, comps)
+	}
+
+	return c, nil
+}
+
+// parseComparator parses a comparator which may be a shorthand, e.g. "~1.4" for ">=1.4.0 <1.5.0-0".
+// Nothing is returned for wildcards satisfied by any version.
+func parseComparator(str string) ([]comparator, error) {
+	m := comparatorRegex.FindStringSubmatch(str)
+	if m == nil {
+		return nil, fmt.Errorf("invalid comparator <%s>", str)
+	}
+
+	op := m[1]
+	var nums [3]int
+	p := partialVersion{pre: m[5]}
+	for _, s := range m[2:5] {
+		if s == "" || s == "x" || s == "X" || s == "*" {
+			break
+		}
+		nums[p.n], _ = strconv.Atoi(s)
+		p.n++
+	}
+	p.major, p.minor, p.patch = nums[0], nums[1], nums[2]
+	if p.pre != "" && p.n < 3 {
+		return nil, fmt.Errorf("pre-release without full version <%s>", str)
+	}
+
+	lower := &VersionTag{Major: p.major, Minor: p.minor, Patch: p.patch, Pre: p.pre}
+	exact := comparator{version: lower, pre: p.pre != ""}
+
+	switch op {
+	case "", "=":
+		if p.n == 3 {
+			exact.op = "="
+			return []comparator{exact}, nil
+		}
+		return p.wildcard(), nil
+	case "~":
+		if p.n < 2 {
+			return p.wildcard(), nil
+		}
+		exact.op = ">="
+		return []comparator{exact, {op: "<", version: upperBound(p.major, p.minor+1, 0)}}, nil
+	case "^":
+		if p.n == 0 {
+			return nil, nil
+		}
+		exact.op = ">="
+		switch {
+		case p.major > 0 || p.n == 1:
+			return []comparator{exact, {op: "<", version: upperBound(p.major+1, 0, 0)}}, nil
+		case p.minor > 0 || p.n == 2:
+			return []comparator{exact, {op: "<", version: upperBound(0, p.minor+1, 0)}}, nil
+		default:
+			return []comparator{exact, {op: "<", version: upperBound(0, 0, p.patch+1)}}, nil
+		}
+	case ">":
+		switch p.n {
+		case 0:
+			return []comparator{{op: "<", version: upperBound(0, 0, 0)}}, nil // nothing is greater than any version
+		case 1:
+			return []comparator{{op: ">=", version: &VersionTag{Major: p.major + 1}}}, nil
+		case 2:
+			return []comparator{{op: ">=", version: &VersionTag{Major: p.major, Minor: p.minor + 1}}}, nil
+		}
+	case ">=":
+		if p.n == 0 {
+			return nil, nil
+		}
+	case "<":
+		switch p.n {
+		case 0:
+			return []comparator{{op: "<", version: upperBound(0, 0, 0)}}, nil
+		case 1, 2:
+			return []comparator{{op: "<", version: upperBound(p.major, p.minor, 0)}}, nil
+		}
+	case "<=":
+		switch p.n {
+		case 0:
+			return nil, nil
+		case 1:
+			return []comparator{{op: "<", version: upperBound(p.major+1, 0, 0)}}, nil
+		case 2:
+			return []comparator{{op: "<", version: upperBound(p.major, p.minor+1, 0)}}, nil
+		}
+	}
+
+	exact.op = op
+	return []comparator{exact}, nil
+}
+
+// wildcard returns comparators of "1.x" or "1.4.x", which are satisfied by versions of the given numbers.
+func (p partialVersion) wildcard() []comparator {
+	switch p.n {
+	case 1:
+		return []comparator{{op: ">=", version: &VersionTag{Major: p.major}}, {op: "<", version: upperBound(p.major+1, 0, 0)}}
+	case 2:
+		return []comparator{{op: ">=", version: &VersionTag{Major: p.major, Minor: p.minor}}, {op: "<", version: upperBound(p.major, p.minor+1, 0)}}
+	default:
+		return nil
+	}
+}
+
+// upperBound returns the lowest pre-release of version, so an exclusive bound excludes its pre-releases too.
+func upperBound(major, minor, patch int) *VersionTag {
+	return &VersionTag{Major: major, Minor: minor, Patch: patch, Pre: "0"}
+}
+
+func (c comparator) check(v *VersionTag) bool {
+	cmp := Compare(v, c.version)
+	switch c.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	default:
+		return cmp == 0
+	}
+}
+
+func (c *Constraint) String() string {
+	return c.str
+}
+
+// Check tells whether v satisfies the constraint. Tag prefix and build-metadata are ignored.
+func (c *Constraint) Check(v *VersionTag) bool {
+	for _, comps := range c.ranges {
+		if checkRange(comps, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkRange(comps []comparator, v *VersionTag) bool {
+	for _, comp := range comps {
+		if !comp.check(v) {
+			return false
+		}
+	}
+
+	if v.Pre == "" {
+		return true
+	}
+	for _, comp := range comps {
+		cv := comp.version
+		if comp.pre && cv.Major == v.Major && cv.Minor == v.Minor && cv.Patch == v.Patch {
+			return true
+		}
+	}
+	return false
+}
+
+// Latest returns the version tag of the highest precedence which satisfies the constraint, or nil if none.
+func (c *Constraint) Latest(tags []*VersionTag) *VersionTag {
+	var latest *VersionTag
+	for _, current := range tags {
+		if c.Check(current) && (latest == nil || Compare(current, latest) > 0) {
+			latest = current
+		}
+	}
+	return latest
+}
+
+// versionTags returns the version tags of repository parsed by parseTag with template.
+func versionTags(r *git.Repository, template *TagTemplate) ([]*VersionTag, error) {
+	tags, err := r.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []*VersionTag
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		version, err := parseTag(ref, template)
+		if err != nil {
+			return nil
+		}
+
+		versions = append(versions, version)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
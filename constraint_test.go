@@ -0,0 +1,99 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstraint_Check(t *testing.T) {
+	cases := map[string]map[string]bool{
+		"~1.4": {
+			"v1.4.0": true, "v1.4.9": true, "v1.5.0": false, "v1.3.9": false, "v1.5.0-rc.1": false, "v1.4.3-rc.1": false,
+		},
+		"~1.4.2": {
+			"v1.4.2": true, "v1.4.9": true, "v1.4.1": false, "v1.5.0": false,
+		},
+		"^1.2.0": {
+			"v1.2.0": true, "v1.9.9": true, "v2.0.0": false, "v1.1.9": false, "v2.0.0-rc.1": false,
+		},
+		"^0.2.3": {
+			"v0.2.3": true, "v0.2.9": true, "v0.3.0": false,
+		},
+		"^0.0.3": {
+			"v0.0.3": true, "v0.0.4": false,
+		},
+		">=1.0.0 <2.0.0": {
+			"v1.0.0": true, "v1.9.9": true, "v2.0.0": false, "v0.9.0": false, "v1.9.0-rc.1": false, "v2.0.0-rc.1": false,
+		},
+		">= 1.0.0, <2.0.0": {},
+		"1.x": {
+			"v1.0.0": true, "v1.9.9": true, "v2.0.0": false, "v0.9.0": false,
+		},
+		"1.4.x": {
+			"v1.4.0": true, "v1.5.0": false,
+		},
+		"*": {
+			"v0.0.1": true, "v9.9.9": true, "v1.0.0-rc.1": false,
+		},
+		"1.2.3": {
+			"v1.2.3": true, "v1.2.3+build.5": true, "v1.2.4": false,
+		},
+		">1.4": {
+			"v1.5.0": true, "v1.4.9": false,
+		},
+		"<=1.4": {
+			"v1.4.9": true, "v1.5.0": false, "v1.5.0-rc.1": false,
+		},
+		">=1.5.0-rc.1 <1.5.0": {
+			"v1.5.0-rc.1": true, "v1.5.0-rc.2": true, "v1.5.0-beta.1": false, "v1.5.0": false, "v1.6.0-rc.1": false,
+		},
+		"^1.0.0 || ^3.0.0": {
+			"v1.2.0": true, "v3.1.0": true, "v2.0.0": false,
+		},
+	}
+
+	for str, versions := range cases {
+		c, err := ParseConstraint(str)
+		if !assert.NoError(t, err, str) {
+			continue
+		}
+		for version, ok := range versions {
+			v, err := VersionFromString(version)
+			assert.NoError(t, err)
+			assert.Equal(t, ok, c.Check(v), "%s satisfies %s", version, str)
+		}
+	}
+
+	for _, str := range []string{"", "1.2.3 ||", "~>1.4", "1.2-rc.1", ">=1.0.0 <two", "1.2.3.4"} {
+		_, err := ParseConstraint(str)
+		assert.Error(t, err, str)
+	}
+}
+
+func TestConstraint_Latest(t *testing.T) {
+	r := newTestRepo(t, "main")
+	first := r.commit("first")
+	r.tag("v1.3.0", first)
+	r.annotatedTag("v1.4.1", first)
+	r.tag("v1.4.2-rc.1", first)
+	r.tag("v2.0.0", first)
+	r.tag("1.4.5", first) // another prefix
+	r.tag("api/v1.4.6", first)
+
+	tags, err := versionTags(r.Repository, &TagTemplate{Prefix: "v"})
+	assert.NoError(t, err)
+	assert.Len(t, tags, 4)
+
+	c, err := ParseConstraint("~1.4")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.4.1", c.Latest(tags).String())
+
+	c, err = ParseConstraint("<2.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.4.1", c.Latest(tags).String())
+
+	c, err = ParseConstraint("^3")
+	assert.NoError(t, err)
+	assert.Nil(t, c.Latest(tags))
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+// Exit codes of action, so scripts can tell failures apart.
+const (
+	ExitFailure        = 1 // unexpected errors
+	ExitConfig         = 2
+	ExitNotRepository  = 3
+	ExitBranchMismatch = 4
+	ExitAuth           = 5
+	ExitPushRejected   = 6
+	ExitTagExists      = 7
+	ExitInvalidVersion = 8
+	ExitNoMatchingTag  = 9
+	ExitChannelOrder   = 10
+)
+
+// exitCoder is an error with its own exit code.
+type exitCoder interface {
+	ExitCode() int
+}
+
+func (e *ConfigError) ExitCode() int {
+	return ExitConfig
+}
+
+// NotRepositoryError tells repo_path is not a git repository.
+type NotRepositoryError struct {
+	Path string
+	Err  error
+}
+
+func (e *NotRepositoryError) Error() string {
+	return fmt.Sprintf("not a git repository: %s, check out repository before this action or set repo_path: %s", e.Path, e.Err)
+}
+
+func (e *NotRepositoryError) Unwrap() error {
+	return e.Err
+}
+
+func (e *NotRepositoryError) ExitCode() int {
+	return ExitNotRepository
+}
+
+// BranchMismatchError tells HEAD doesn't match the branch patterns of strategies.
+type BranchMismatchError struct {
+	Branch   string
+	Patterns []string
+}
+
+func (e *BranchMismatchError) Error() string {
+	return fmt.Sprintf("branch %s doesn't match any of %v, set branches or strategy", e.Branch, e.Patterns)
+}
+
+func (e *BranchMismatchError) ExitCode() int {
+	return ExitBranchMismatch
+}
+
+// AuthError tells the remote refused the credentials, or the credentials couldn't be loaded.
+type AuthError struct {
+	Remote string
+	Auth   string
+	Err    error
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("failed to authenticate to remote %s with %s auth, check auth and its credentials: %s", e.Remote, e.Auth, e.Err)
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+func (e *AuthError) ExitCode() int {
+	return ExitAuth
+}
+
+// PushRejectedError tells the remote rejected tags for other reasons than existing tags, e.g. protected tags.
+type PushRejectedError struct {
+	Remote string
+	Tags   []string
+	Err    error
+}
+
+func (e *PushRejectedError) Error() string {
+	return fmt.Sprintf("remote %s rejected %s: %s", e.Remote, strings.Join(e.Tags, ", "), e.Err)
+}
+
+func (e *PushRejectedError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PushRejectedError) ExitCode() int {
+	return ExitPushRejected
+}
+
+// TagExistsError tells the version tag already exists on another commit, locally or on Remote.
+type TagExistsError struct {
+	Tag    string
+	Remote string // empty for local tags
+	Reason string
+}
+
+func (e *TagExistsError) Error() string {
+	msg := fmt.Sprintf("tag %s already exists", e.Tag)
+	if e.Remote != "" {
+		msg += " on remote " + e.Remote
+	}
+	if e.Reason != "" {
+		msg += ", " + e.Reason
+	}
+	return msg
+}
+
+func (e *TagExistsError) ExitCode() int {
+	return ExitTagExists
+}
+
+// InvalidVersionError tells the version is not a semantic version with optional prefix.
+type InvalidVersionError struct {
+	Version string
+}
+
+func (e *InvalidVersionError) Error() string {
+	return fmt.Sprintf("invalid version: %s, must be a semantic version with optional prefix, e.g. v1.2.3-rc.1+build.5", e.Version)
+}
+
+func (e *InvalidVersionError) ExitCode() int {
+	return ExitInvalidVersion
+}
+
+// NoMatchingTagError tells no version tag satisfies the constraint.
+type NoMatchingTagError struct {
+	Constraint string
+}
+
+func (e *NoMatchingTagError) Error() string {
+	return fmt.Sprintf("no version tag satisfies %s", e.Constraint)
+}
+
+func (e *NoMatchingTagError) ExitCode() int {
+	return ExitNoMatchingTag
+}
+
+// UsageError tells the arguments of command are invalid.
+type UsageError struct {
+	Command string
+	Reason  string
+}
+
+func (e *UsageError) Error() string {
+	return fmt.Sprintf("%s: %s, see -h for usage", e.Command, e.Reason)
+}
+
+func (e *UsageError) ExitCode() int {
+	return ExitConfig
+}
+
+// authError wraps errors of transport refusing the credentials into AuthError.
+func authError(cfg *Config, err error) error {
+	if errors.Is(err, transport.ErrAuthenticationRequired) || errors.Is(err, transport.ErrAuthorizationFailed) {
+		return &AuthError{Remote: cfg.Remote, Auth: cfg.Auth, Err: err}
+	}
+	return err
+}
+
+// handleError prints err as an error annotation of GitHub Actions, and returns its exit code.
+// Errors of the config file point to the file.
+func handleError(w io.Writer, err error) int {
+	var loc *Location
+	var cfgErr *ConfigError
+	if errors.As(err, &cfgErr) && cfgErr.File != "" {
+		loc = &Location{File: cfgErr.File, Line: cfgErr.Line}
+	}
+	NewLogger(w, false).Error(loc, err.Error())
+	return exitCode(err)
+}
+
+// exitCode returns the exit code of err, or ExitFailure for unexpected errors.
+func exitCode(err error) int {
+	var e exitCoder
+	if errors.As(err, &e) {
+		return e.ExitCode()
+	}
+	return ExitFailure
+}
+
+// ChannelOrderError tells a pre-release in the channel would move backwards from the existing tag of the version,
+// which is in a later channel or the release itself.
+type ChannelOrderError struct {
+	Version  string
+	Channel  string
+	Existing string
+}
+
+func (e *ChannelOrderError) Error() string {
+	return fmt.Sprintf("%s pre-release of %s would move backwards from %s, channels are in order of prerelease_channels", e.Channel, e.Version, e.Existing)
+}
+
+func (e *ChannelOrderError) ExitCode() int {
+	return ExitChannelOrder
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleError(t *testing.T) {
+	cases := map[error]int{
+		errors.New("unexpected"):                                   ExitFailure,
+		&ConfigError{Key: "auth", Reason: "invalid"}:               ExitConfig,
+		&BranchMismatchError{Branch: "refs/heads/feature/foo"}:     ExitBranchMismatch,
+		&PushRejectedError{Remote: "origin", Tags: []string{"v1"}}: ExitPushRejected,
+		&TagExistsError{Tag: "v1.0.0"}:                             ExitTagExists,
+		fmt.Errorf("component api: %w", &TagExistsError{}):         ExitTagExists,
+		&InvalidVersionError{Version: "v1.2"}:                      ExitInvalidVersion,
+		&UsageError{Command: "validate"}:                           ExitConfig,
+		&NoMatchingTagError{Constraint: "^2"}:                      ExitNoMatchingTag,
+		&ChannelOrderError{Version: "v1.5.0", Channel: "beta"}:     ExitChannelOrder,
+	}
+	for err, code := range cases {
+		assert.Equal(t, code, handleError(&bytes.Buffer{}, err), err.Error())
+	}
+
+	var buf bytes.Buffer
+	handleError(&buf, errors.New("100% failed\nsecond line"))
+	assert.Equal(t, "::error::100%25 failed%0Asecond line\n", buf.String())
+}
+
+func TestOpenRepository(t *testing.T) {
+	_, err := openRepository(t.TempDir())
+	if assert.IsType(t, &NotRepositoryError{}, err) {
+		assert.Equal(t, ExitNotRepository, err.(*NotRepositoryError).ExitCode())
+	}
+}
+
+func TestAuthError(t *testing.T) {
+	cfg := testConfig(t, func(c *Config) { c.RepoToken = "secret" })
+
+	err := authError(cfg, transport.ErrAuthenticationRequired)
+	if assert.IsType(t, &AuthError{}, err) {
+		assert.Equal(t, "token", err.(*AuthError).Auth)
+		assert.True(t, errors.Is(err, transport.ErrAuthenticationRequired))
+	}
+
+	assert.Equal(t, transport.ErrRepositoryNotFound, authError(cfg, transport.ErrRepositoryNotFound))
+
+	_, err = newAuth(testConfig(t, func(c *Config) { c.SSHPrivateKey = "not a key" }))
+	assert.IsType(t, &AuthError{}, err)
+}
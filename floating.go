@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// floatingParts are the parts of version which floating tags may follow.
+var floatingParts = []string{"major", "minor"}
+
+// floatingTags returns the names of floating tags of version, e.g. v1 and v1.4 for v1.4.7, of the configured parts.
+// A floating tag is moved only if version is the highest release of its line among tags,
+// so patches of older lines don't move it back. Pre-releases move no floating tags.
+func floatingTags(cfg *Config, version *VersionTag, tags []*VersionTag) []string {
+	if version.Pre != "" {
+		return nil
+	}
+
+	var names []string
+	for _, part := range cfg.FloatingTags {
+		line := &versionLine{Major: version.Major, Parts: 1}
+		name := fmt.Sprintf("%d", version.Major)
+		if part == "minor" {
+			line = &versionLine{Major: version.Major, Minor: version.Minor, Parts: 2}
+			name = fmt.Sprintf("%d.%d", version.Major, version.Minor)
+		}
+
+		name = version.Tag + name + version.Suffix
+		if higher := higherRelease(version, line, tags); higher != nil {
+			Info("Floating tag %s is not moved, %s is higher than %s", name, higher.String(), version.String())
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// higherRelease returns a release of line among tags which is higher than version, or nil if none.
+func higherRelease(version *VersionTag, line *versionLine, tags []*VersionTag) *VersionTag {
+	for _, t := range tags {
+		if t.Pre == "" && line.contains(t) && Compare(t, version) > 0 {
+			return t
+		}
+	}
+	return nil
+}
+
+// moveFloatingTags points the floating tags of planned versions to HEAD, which the version tags are created on.
+// It returns refspecs to push them, which overwrite the remote tags, and names of the floating tags.
+// Nothing is moved in dry run.
+func moveFloatingTags(r *git.Repository, cfg *Config, plans []*plannedTag) ([]config.RefSpec, []string, error) {
+	if len(cfg.FloatingTags) == 0 || len(plans) == 0 {
+		return nil, nil, nil
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var refSpecs []config.RefSpec
+	var names []string
+	for _, p := range plans {
+		tags, err := versionTags(r, cfg.template(p.Component))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		p.Floating = floatingTags(cfg, p.Next.Version, tags)
+		for _, name := range p.Floating {
+			refSpec := fmt.Sprintf("+refs/tags/%s:refs/tags/%s", name, name)
+			refSpecs = append(refSpecs, config.RefSpec(refSpec))
+			names = append(names, name)
+
+			if cfg.DryRun {
+				Info("Dry run, floating tag %s is not moved to %s", name, head.Hash().String())
+				continue
+			}
+
+			Info("Move floating tag %s to %s", name, head.Hash().String())
+			ref := plumbing.NewHashReference(plumbing.NewTagReferenceName(name), head.Hash())
+			if err := r.Storer.SetReference(ref); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return refSpecs, names, nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFloatingTags(t *testing.T) {
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.FloatingTags = []string{"major", "minor"}
+	})
+
+	var tags []*VersionTag
+	for _, name := range []string{"v1.4.6", "v1.5.0", "v1.5.1-rc.1", "v2.0.0"} {
+		v, err := VersionFromString(name)
+		assert.NoError(t, err)
+		tags = append(tags, v)
+	}
+
+	cases := map[string][]string{
+		"v1.4.7":      {"v1.4"}, // a patch of the older line doesn't move v1
+		"v1.5.1":      {"v1", "v1.5"},
+		"v1.6.0":      {"v1", "v1.6"},
+		"v2.0.1":      {"v2", "v2.0"},
+		"v1.3.9":      {"v1.3"},
+		"v1.4.5":      nil,
+		"v1.6.0-rc.1": nil,
+	}
+	for version, expected := range cases {
+		v, err := VersionFromString(version)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, floatingTags(cfg, v, tags), version)
+	}
+
+	v := &VersionTag{Major: 1, Minor: 2, Patch: 3, Suffix: "-linux"}
+	assert.Equal(t, []string{"1-linux", "1.2-linux"}, floatingTags(cfg, v, nil))
+
+	cfg = testConfig(t, func(cfg *Config) {
+		cfg.FloatingTags = []string{"major"}
+	})
+	v, err := VersionFromString("v2.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"v2"}, floatingTags(cfg, v, tags))
+}
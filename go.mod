@@ -5,5 +5,6 @@ go 1.16
 require (
 	github.com/go-git/go-git/v5 v5.1.0
 	github.com/stretchr/testify v1.4.0
-	golang.org/x/crypto v0.0.0-20200602180216-279210d13fed // indirect
+	golang.org/x/crypto v0.0.0-20200602180216-279210d13fed
+	gopkg.in/yaml.v2 v2.2.4
 )
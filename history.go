@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// initialDeepenDepth is the depth of history fetched first for shallow repositories, which is doubled until enough.
+const initialDeepenDepth = 50
+
+// tagCommit returns the commit of tag, peeling annotated tags.
+// The commit itself may be missing in shallow repository.
+func tagCommit(r *git.Repository, ref *plumbing.Reference) (plumbing.Hash, error) {
+	tag, err := r.TagObject(ref.Hash())
+	if err == plumbing.ErrObjectNotFound {
+		return ref.Hash(), nil // lightweight tag
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return tag.Target, nil
+}
+
+// shallowCommits returns the set of shallow commits, whose parents are missing until the history is deepened.
+func shallowCommits(r *git.Repository) (map[plumbing.Hash]bool, error) {
+	shallows, err := r.Storer.Shallow()
+	if err != nil {
+		return nil, err
+	}
+
+	set := map[plumbing.Hash]bool{}
+	for _, h := range shallows {
+		set[h] = true
+	}
+	return set, nil
+}
+
+// ancestors returns the commits in the history of from, including from itself, as far as it is fetched.
+// It tells whether the history is cut at shallow or missing commits, so older commits are unknown.
+func ancestors(r *git.Repository, from plumbing.Hash) (map[plumbing.Hash]bool, bool, error) {
+	stop, err := shallowCommits(r)
+	if err != nil {
+		return nil, false, err
+	}
+
+	cut := false
+	seen := map[plumbing.Hash]bool{}
+	queue := []plumbing.Hash{from}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+
+		if seen[h] {
+			continue
+		}
+
+		c, err := r.CommitObject(h)
+		if err == plumbing.ErrObjectNotFound {
+			cut = true
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		seen[h] = true
+
+		if stop[h] {
+			cut = true
+			continue
+		}
+		queue = append(queue, c.ParentHashes...)
+	}
+	return seen, cut, nil
+}
+
+// reachable tells whether target is in the history of from, as far as it is fetched.
+// Parents of shallow commits are not walked, since they are missing.
+func reachable(r *git.Repository, from, target plumbing.Hash) (bool, error) {
+	stop, err := shallowCommits(r)
+	if err != nil {
+		return false, err
+	}
+
+	seen := map[plumbing.Hash]bool{}
+	queue := []plumbing.Hash{from}
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+
+		if h == target {
+			return true, nil
+		}
+		if seen[h] {
+			continue
+		}
+		seen[h] = true
+
+		c, err := r.CommitObject(h)
+		if err == plumbing.ErrObjectNotFound {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+
+		if !stop[h] {
+			queue = append(queue, c.ParentHashes...)
+		}
+	}
+	return false, nil
+}
+
+// inHistory tells whether the commit of tag is in the history of h. A tag beyond shallow history counts,
+// as it may be, until the history is deepened.
+func inHistory(r *git.Repository, tag string, h plumbing.Hash) (bool, error) {
+	ref, err := r.Tag(tag)
+	if err != nil {
+		return false, err
+	}
+
+	target, err := tagCommit(r, ref)
+	if err != nil {
+		return false, err
+	}
+
+	history, cut, err := ancestors(r, h)
+	if err != nil {
+		return false, err
+	}
+	return history[target] || cut, nil
+}
+
+// ensureHistory deepens shallow repository until the commit of each previous tag is reachable from HEAD,
+// since commits between them decide the next version. A tag which isn't reachable even with the full history,
+// e.g. of another branch, is no previous version of HEAD, and is left out once the versions are recomputed.
+// It tells whether the history was deepened, so the versions should be recomputed.
+func ensureHistory(r *git.Repository, cfg *Config, plans []*plannedTag) (bool, error) {
+	shallows, err := r.Storer.Shallow()
+	if err != nil || len(shallows) == 0 {
+		return false, err
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return false, err
+	}
+
+	deepened := false
+	depth := initialDeepenDepth
+	for _, p := range plans {
+		if p.Next == nil || p.Next.Previous == nil {
+			continue
+		}
+		previous := p.Next.Previous
+
+		target, err := tagCommit(r, previous.ref)
+		if err != nil {
+			return deepened, err
+		}
+
+		for {
+			ok, err := reachable(r, head.Hash(), target)
+			if err != nil {
+				return deepened, err
+			}
+			if ok {
+				break
+			}
+
+			before, err := r.Storer.Shallow()
+			if err != nil {
+				return deepened, err
+			}
+			if len(before) == 0 {
+				Info("Previous tag %s is not in the history of HEAD, skip it", previous)
+				break
+			}
+
+			Info("Shallow repository, deepen history to %d commits to find previous tag %s", depth, previous)
+			if err := deepen(r, cfg, head.Hash(), depth); err != nil {
+				return deepened, fmt.Errorf("failed to deepen history to find previous tag %s: %w", previous, err)
+			}
+			deepened = true
+
+			after, err := r.Storer.Shallow()
+			if err != nil {
+				return deepened, err
+			}
+			if sameHashes(before, after) {
+				return deepened, fmt.Errorf("commit %s of previous tag %s is not reachable from HEAD, and history can't be deepened beyond %d commits", target, previous, depth)
+			}
+
+			depth *= 2
+		}
+	}
+	return deepened, nil
+}
+
+func sameHashes(a, b []plumbing.Hash) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	set := map[plumbing.Hash]bool{}
+	for _, h := range a {
+		set[h] = true
+	}
+	for _, h := range b {
+		if !set[h] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+)
+
+// shallowClone clones main branch of origin with only the latest commit.
+func shallowClone(t *testing.T, origin *testRepo) *testRepo {
+	w, err := origin.Worktree()
+	assert.NoError(t, err)
+
+	clone, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{
+		URL:           w.Filesystem.Root(),
+		ReferenceName: plumbing.NewBranchReferenceName("main"),
+		SingleBranch:  true,
+		Depth:         1,
+		Tags:          git.NoTags,
+	})
+	assert.NoError(t, err)
+
+	shallows, err := clone.Storer.Shallow()
+	assert.NoError(t, err)
+	assert.Len(t, shallows, 1)
+
+	return &testRepo{t: t, Repository: clone}
+}
+
+func TestTagAndPush_Shallow(t *testing.T) {
+	origin := newTestRepo(t, "main")
+	origin.annotatedTag("v1.0.0", origin.commit("chore: first"))
+	origin.commit("feat: second")
+	origin.commit("fix: third")
+	origin.commit("fix: fourth")
+
+	r := shallowClone(t, origin)
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.DryRun = true
+	})
+
+	outputs := NewOutputs()
+	plans, err := tagAndPush(r.Repository, cfg, nil, outputs)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Equal(t, "v1.0.0", previousTag(plans[0].Next.Previous))
+		assert.Len(t, plans[0].Commits, 3)
+	}
+	assert.Equal(t, "v1.1.0", outputs.Get("new_tag"))
+
+	shallows, err := r.Storer.Shallow()
+	assert.NoError(t, err)
+	assert.Empty(t, shallows)
+}
+
+func TestTagAndPush_ShallowComponents(t *testing.T) {
+	origin := newTestRepo(t, "main")
+	origin.annotatedTag("api/v1.0.0", origin.commitFile("api/main.go", "chore: first"))
+	origin.commitFile("api/handler.go", "feat: api")
+	origin.commitFile("web/index.html", "fix: web")
+
+	// HEAD doesn't change api, and its parent is missing
+	r := shallowClone(t, origin)
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.DryRun = true
+		cfg.Components = []Component{{Name: "api", Paths: []string{"api"}}}
+	})
+
+	outputs := NewOutputs()
+	plans, err := tagAndPush(r.Repository, cfg, nil, outputs)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Equal(t, "api/v1.0.0", previousTag(plans[0].Next.Previous))
+		assert.Len(t, plans[0].Commits, 1)
+	}
+	assert.Equal(t, "api/v1.1.0", outputs.Get("new_tags"))
+
+	shallows, err := r.Storer.Shallow()
+	assert.NoError(t, err)
+	assert.Empty(t, shallows)
+}
+
+func TestTagAndPush_ShallowUnreachable(t *testing.T) {
+	origin := newTestRepo(t, "main")
+	first := origin.commit("chore: first")
+	origin.tag("v1.0.0", first)
+	origin.commit("feat: second")
+
+	// the latest release is tagged on another branch
+	w, err := origin.Worktree()
+	assert.NoError(t, err)
+	assert.NoError(t, w.Checkout(&git.CheckoutOptions{Hash: first, Branch: plumbing.NewBranchReferenceName("other"), Create: true}))
+	origin.tag("v2.0.0", origin.commit("feat!: other"))
+	assert.NoError(t, w.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("main")}))
+
+	r := shallowClone(t, origin)
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.DryRun = true
+	})
+
+	// the full history tells the tag isn't a previous version of HEAD
+	outputs := NewOutputs()
+	plans, err := tagAndPush(r.Repository, cfg, nil, outputs)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Equal(t, "v1.0.0", previousTag(plans[0].Next.Previous))
+		assert.Len(t, plans[0].Commits, 1)
+	}
+	assert.Equal(t, "v1.1.0", outputs.Get("new_tag"))
+
+	shallows, err := r.Storer.Shallow()
+	assert.NoError(t, err)
+	assert.Empty(t, shallows)
+}
+
+func TestReachable(t *testing.T) {
+	r := newTestRepo(t, "main")
+	first := r.commit("first")
+	second := r.commit("second")
+
+	ok, err := reachable(r.Repository, second, first)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = reachable(r.Repository, first, second)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// history is cut at shallow commits
+	assert.NoError(t, r.Storer.SetShallow([]plumbing.Hash{second}))
+	ok, err = reachable(r.Repository, second, first)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Logger writes logs as workflow commands of GitHub Actions, so warnings and errors are shown as annotations.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+type Logger struct {
+	w     io.Writer
+	debug bool
+}
+
+// NewLogger returns a logger writing to w. Debug logs are written only with debug.
+func NewLogger(w io.Writer, debug bool) *Logger {
+	return &Logger{w: w, debug: debug}
+}
+
+// logger is enabled for debug logs when the workflow is re-run with debug logging, which sets RUNNER_DEBUG.
+var logger = NewLogger(os.Stdout, os.Getenv("RUNNER_DEBUG") == "1")
+
+// Location points an annotation to a line of file in repository. Line is optional.
+type Location struct {
+	File string
+	Line int
+}
+
+func (l *Logger) command(name string, loc *Location, message string) {
+	props := ""
+	if loc != nil && loc.File != "" {
+		props = " file=" + escapeProperty(loc.File)
+		if loc.Line > 0 {
+			props += ",line=" + strconv.Itoa(loc.Line)
+		}
+	}
+	fmt.Fprintf(l.w, "::%s%s::%s\n", name, props, escapeData(message))
+}
+
+func (l *Logger) Debug(format string, args ...interface{}) {
+	if l.debug {
+		l.command("debug", nil, fmt.Sprintf(format, args...))
+	}
+}
+
+func (l *Logger) Info(format string, args ...interface{}) {
+	fmt.Fprintln(l.w, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Notice(format string, args ...interface{}) {
+	l.command("notice", nil, fmt.Sprintf(format, args...))
+}
+
+func (l *Logger) Warning(format string, args ...interface{}) {
+	l.command("warning", nil, fmt.Sprintf(format, args...))
+}
+
+// Error writes an error annotation, pointing to loc if not nil.
+func (l *Logger) Error(loc *Location, message string) {
+	l.command("error", loc, message)
+}
+
+// Verbatim writes text from others, e.g. commit messages, as it is. Lines of text looking like workflow commands,
+// e.g. ::add-mask:: or ::error::, are not run, as commands are stopped until a random token which text doesn't contain.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#stopping-and-starting-workflow-commands
+func (l *Logger) Verbatim(text string) {
+	token, err := randomDelimiter("ghastop_", text)
+	if err != nil {
+		l.Warning("Failed to stop workflow commands, text is not logged: %s", err)
+		return
+	}
+
+	fmt.Fprintf(l.w, "::stop-commands::%s\n", token)
+	fmt.Fprintln(l.w, strings.TrimRight(text, "\n"))
+	fmt.Fprintf(l.w, "::%s::\n", token)
+}
+
+// Group folds the following logs until EndGroup under title.
+func (l *Logger) Group(title string) {
+	l.command("group", nil, title)
+}
+
+func (l *Logger) EndGroup() {
+	fmt.Fprintln(l.w, "::endgroup::")
+}
+
+// Mask hides secret in the following logs. Each line is masked separately, as masks are matched by line.
+func (l *Logger) Mask(secret string) {
+	for _, line := range strings.Split(secret, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			l.command("add-mask", nil, line)
+		}
+	}
+}
+
+// Debug should be used to describe details which are needed only to investigate problems.
+func Debug(format string, args ...interface{}) {
+	logger.Debug(format, args...)
+}
+
+// Info should be used to describe the example commands that are about to run.
+func Info(format string, args ...interface{}) {
+	logger.Info(format, args...)
+}
+
+// Verbatim should be used to display text from others, such as commit messages, which may look like workflow commands.
+func Verbatim(text string) {
+	logger.Verbatim(text)
+}
+
+// Notice should be used to highlight the result of run.
+func Notice(format string, args ...interface{}) {
+	logger.Notice(format, args...)
+}
+
+// Warning should be used to display a warning
+func Warning(format string, args ...interface{}) {
+	logger.Warning(format, args...)
+}
+
+// escapeData escapes the message of workflow command, which ends at a line break.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes the property value of workflow command, which also ends at colon or comma.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf, false)
+
+	l.Group("Compute version")
+	l.Debug("hidden")
+	l.Info("Bump %s version", "minor")
+	l.Notice("Success to bump version: %s", "v1.1.0")
+	l.Warning("100%% done\nreally")
+	l.Error(&Location{File: ".github/tag-action.yml", Line: 3}, "invalid")
+	l.EndGroup()
+
+	assert.Equal(t, "::group::Compute version\n"+
+		"Bump minor version\n"+
+		"::notice::Success to bump version: v1.1.0\n"+
+		"::warning::100%25 done%0Areally\n"+
+		"::error file=.github/tag-action.yml,line=3::invalid\n"+
+		"::endgroup::\n", buf.String())
+
+	buf.Reset()
+	NewLogger(&buf, true).Debug("shown")
+	assert.Equal(t, "::debug::shown\n", buf.String())
+}
+
+func TestLogger_Verbatim(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf, false)
+
+	l.Verbatim("fix: typo\n\n::add-mask::token\n::error::injected\n")
+
+	lines := strings.Split(buf.String(), "\n")
+	if assert.Len(t, lines, 7) {
+		token := strings.TrimPrefix(lines[0], "::stop-commands::")
+		assert.Regexp(t, "^ghastop_[0-9a-f]{32}$", token)
+		assert.Equal(t, []string{"fix: typo", "", "::add-mask::token", "::error::injected", "::" + token + "::", ""}, lines[1:])
+	}
+}
+
+func TestLogger_Mask(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewLogger(&buf, false)
+
+	l.Mask("")
+	l.Mask("secret")
+	l.Mask("first\n\n second \n")
+
+	assert.Equal(t, "::add-mask::secret\n::add-mask::first\n::add-mask::second\n", buf.String())
+}
+
+func TestHandleError_ConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, ".github"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, ".github", "tag-action.yml"), []byte("tag_prefix: v\ntag_prefx: v\n"), 0644))
+	setEnv(t, "INPUT_REPO_PATH", dir)
+
+	_, err := LoadConfig()
+
+	var buf bytes.Buffer
+	assert.Equal(t, ExitConfig, handleError(&buf, err))
+	assert.Contains(t, buf.String(), "::error file="+filepath.Join(dir, ".github", "tag-action.yml")+",line=2::")
+}
@@ -1,28 +1,27 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
-	"log"
+	"golang.org/x/crypto/openpgp"
 	"os"
 	"regexp"
 	"strconv"
-	"time"
+	"strings"
 )
 
 type VersionTag struct {
-	ref   *plumbing.Reference
-	Tag   string
-	Major int
-	Minor int
-	Patch int
-	Pre   string
-	Build string
+	ref    *plumbing.Reference
+	calver *CalVerFormat // nil for semantic versions
+	Tag    string
+	Major  int
+	Minor  int
+	Patch  int
+	Pre    string
+	Build  string
+	Suffix string // after build-metadata, e.g. -linux of 1.2.3-linux
 }
 
 func (v *VersionTag) String() string {
@@ -30,7 +29,11 @@ func (v *VersionTag) String() string {
 
 	ret += v.Tag // append tag name
 
-	ret += fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch) // append body
+	if v.calver != nil {
+		ret += v.calver.format(v) // append calendar version body
+	} else {
+		ret += fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch) // append body
+	}
 
 	if len(v.Pre) > 0 {
 		ret += "-" + v.Pre //append pre-release
@@ -40,9 +43,144 @@ func (v *VersionTag) String() string {
 		ret += "+" + v.Build // append build-metadata
 	}
 
+	ret += v.Suffix // append tag suffix
+
 	return ret
 }
 
+// Increment increases the given part of version and resets the lower parts.
+// Build-metadata is always cleared, and so is pre-release unless it is the increased part.
+// As in SemVer tools, a pre-release is released by the bump if its lower parts are already reset,
+// e.g. 1.2.3-rc.1 -> 1.2.3 by patch and 1.3.0-rc.1 -> 1.3.0 by minor, instead of skipping its version.
+func (v *VersionTag) Increment(b Bump) {
+	pre := v.Pre != ""
+
+	switch b {
+	case BumpPrerelease:
+		v.Pre = incrementPre(v.Pre)
+		v.Build = ""
+		return
+	case BumpMajor:
+		if !pre || v.Minor != 0 || v.Patch != 0 {
+			v.Major++
+		}
+		v.Minor = 0
+		v.Patch = 0
+	case BumpMinor:
+		if !pre || v.Patch != 0 {
+			v.Minor++
+		}
+		v.Patch = 0
+	case BumpPatch:
+		if !pre {
+			v.Patch++
+		}
+	}
+
+	v.Pre = ""
+	v.Build = ""
+}
+
+// incrementPre increases the last numeric identifier of pre-release: "0" -> "1", "build.9" -> "build.10".
+// A pre-release without numeric identifier at the end gets ".1", and an empty one starts at "0".
+func incrementPre(pre string) string {
+	if pre == "" {
+		return "0"
+	}
+
+	ids := strings.Split(pre, ".")
+	n, err := strconv.Atoi(ids[len(ids)-1])
+	if err != nil {
+		return pre + ".1"
+	}
+
+	ids[len(ids)-1] = strconv.Itoa(n + 1)
+	return strings.Join(ids, ".")
+}
+
+// Compare returns -1, 0 or +1 when a has lower, equal or higher precedence than b by SemVer 2.0.0.
+// Tag prefix and build-metadata don't affect precedence.
+// See https://semver.org/#spec-item-11
+func Compare(a, b *VersionTag) int {
+	if c := compareInt(a.Major, b.Major); c != 0 {
+		return c
+	}
+	if c := compareInt(a.Minor, b.Minor); c != 0 {
+		return c
+	}
+	if c := compareInt(a.Patch, b.Patch); c != 0 {
+		return c
+	}
+	return comparePre(a.Pre, b.Pre)
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// comparePre compares pre-releases field by field. A version without pre-release has higher precedence,
+// and a larger set of fields has higher precedence when all the preceding ones are equal.
+func comparePre(a, b string) int {
+	if a == b {
+		return 0
+	}
+	if a == "" {
+		return 1
+	}
+	if b == "" {
+		return -1
+	}
+
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareIdentifier(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInt(len(as), len(bs))
+}
+
+// compareIdentifier compares numeric identifiers numerically and the others in ASCII order.
+// Numeric identifiers always have lower precedence than the others.
+func compareIdentifier(a, b string) int {
+	an, bn := isNumeric(a), isNumeric(b)
+	switch {
+	case an && bn:
+		// numeric identifiers have no leading zeros, so the longer is larger without overflow of parsing
+		if c := compareInt(len(a), len(b)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	case an:
+		return -1
+	case bn:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// VersionFromString parses a semantic version with optional prefix, which doesn't end with a digit or dot,
+// e.g. v1.2.3, Release-1.2.3 or sdk/go/v1.2.3. Suffixes are parsed by TagTemplate, as they look like pre-releases.
 func VersionFromString(str string) (*VersionTag, error) {
 	if !semverRegex.MatchString(str) {
 		return nil, fmt.Errorf("invalid tag format: <%s>", str)
@@ -68,140 +206,299 @@ func VersionFromString(str string) (*VersionTag, error) {
 }
 
 var (
-	releaseBranchRegex = regexp.MustCompile("release/(0|[1-9]\\d*)\\.(0|[1-9]\\d*)")
-	semverRegex        = regexp.MustCompile("^([a-z]*)(0|[1-9]\\d*)\\.(0|[1-9]\\d*)\\.(0|[1-9]\\d*)(?:-((?:0|[1-9]\\d*|\\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\\.(?:0|[1-9]\\d*|\\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\\+([0-9a-zA-Z-]+(?:\\.[0-9a-zA-Z-]+)*))?$")
+	semverRegex = regexp.MustCompile("^(|.*[^0-9.])(0|[1-9]\\d*)\\.(0|[1-9]\\d*)\\.(0|[1-9]\\d*)(?:-((?:0|[1-9]\\d*|\\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\\.(?:0|[1-9]\\d*|\\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\\+([0-9a-zA-Z-]+(?:\\.[0-9a-zA-Z-]+)*))?$")
 	//preRegex           = regexp.MustCompile("([a-zA-Z]+\\.)?(0|[1-9]\\d*)")
 )
 
-func workflowRelease(r *git.Repository) (*VersionTag, error) {
-	h, err := r.Head()
-	if err != nil {
-		return nil, err
+func main() {
+	// the action runs without arguments, and commands are for local use
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:], os.Stdout, os.Stderr))
 	}
 
-	if !h.Name().IsBranch() {
-		return nil, errors.New("release workflow must be branch")
+	if err := run(); err != nil {
+		os.Exit(handleError(os.Stdout, err))
 	}
+}
 
-	branchName := h.Name().String()
+func run() error {
+	cfg, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	maskSecrets(cfg)
 
-	if !releaseBranchRegex.MatchString(branchName) {
-		return nil, fmt.Errorf("not matching branch name pattern: wanted %s, got %s ", releaseBranchRegex.String(), branchName)
+	// outputs of tags already pushed are saved even if creating their releases fails
+	outputs := NewOutputs()
+	err = tagRepository(cfg, outputs)
+	if saveErr := outputs.Save(); err == nil {
+		err = saveErr
 	}
+	return err
+}
 
-	major, _ := strconv.Atoi(releaseBranchRegex.FindStringSubmatch(branchName)[1])
-	minor, _ := strconv.Atoi(releaseBranchRegex.FindStringSubmatch(branchName)[2])
+// tagRepository tags the repository of cfg, creates releases if enabled and writes the job summary.
+func tagRepository(cfg *Config, outputs *Outputs) error {
+	r, err := openRepository(cfg.RepoPath)
+	if err != nil {
+		return err
+	}
 
-	tags, err := r.Tags()
+	var signKey *openpgp.Entity
+	if cfg.Sign {
+		signKey, err = loadSignKey(cfg.GPGPrivateKey, cfg.GPGPassphrase, cfg.now())
+		if err != nil {
+			return err
+		}
+		Info("Sign with gpg key: %s", signKey.PrivateKey.KeyIdString())
+	}
+
+	plans, err := tagAndPush(r, cfg, signKey, outputs)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	latest := &VersionTag{
-		ref:   h,
-		Tag:   "v",
-		Major: major,
-		Minor: minor,
-		Patch: 0,
-		Pre:   "",
-		Build: "",
+	if cfg.Release && !cfg.DryRun {
+		if err := publishReleases(r, cfg, plans, outputs); err != nil {
+			return err
+		}
 	}
 
-	err = tags.ForEach(func(ref *plumbing.Reference) error {
-		current, err := parseTag(ref)
+	return writeSummary(cfg, plans)
+}
+
+// publishReleases creates the GitHub releases of planned tags. A version tag HEAD already has gets its release
+// only if it's missing, e.g. when creating it failed after the push, and the workflow is re-run.
+func publishReleases(r *git.Repository, cfg *Config, plans []*plannedTag, outputs *Outputs) error {
+	publisher := NewReleasePublisher(cfg)
+	for _, p := range plans {
+		release, err := publishRelease(r, cfg, publisher, p)
 		if err != nil {
-			return nil
+			return err
 		}
 
-		// not a tag of this release
-		if current.Major != major || current.Minor != minor {
-			return nil
+		if p.Component == nil {
+			outputs.Set("release_url", release.HTMLURL)
+		} else {
+			outputs.Set(p.Component.Name+"_release_url", release.HTMLURL)
 		}
+	}
+	return nil
+}
 
-		if isNewerVersion(latest, current) {
-			latest = current
+func publishRelease(r *git.Repository, cfg *Config, publisher *ReleasePublisher, p *plannedTag) (*Release, error) {
+	version, commits := p.Existing, p.Commits
+	if version == nil {
+		version = p.Next.Version
+	} else {
+		release, err := publisher.Get(version.String())
+		if err != nil {
+			return nil, err
+		}
+		if release != nil {
+			Info("Release of %s already exists: %s", version.String(), release.HTMLURL)
+			return release, nil
 		}
 
-		return nil
+		commits, err = existingTagCommits(r, cfg, p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	release, err := publisher.Create(NewRelease(version, renderReleaseNotes(commits), cfg.ReleaseDraft))
+	if err != nil {
+		return nil, err
+	}
+	Info("Created release: %s", release.HTMLURL)
+	return release, nil
+}
+
+// existingTagCommits returns the commits since the version tag preceding the one HEAD already has,
+// in the history of HEAD, to write the release notes of the existing tag.
+// A release follows the previous release, and a pre-release the previous version tag of any kind.
+func existingTagCommits(r *git.Repository, cfg *Config, p *plannedTag) ([]*object.Commit, error) {
+	ctx, err := newStrategyContext(r, cfg, p.Component)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := ctx.latestInHistory(func(v *VersionTag) bool {
+		return Compare(v, p.Existing) < 0 && (isRelease(v) || !isRelease(p.Existing))
 	})
 	if err != nil {
 		return nil, err
 	}
+	return commitsSince(r, previous, ctx.Paths)
+}
 
-	latest.Patch++
-	latest.Pre = ""
-	latest.Build = ""
+// maskSecrets hides the credentials in logs, in case they are printed by errors of libraries.
+func maskSecrets(cfg *Config) {
+	for _, secret := range []string{cfg.RepoToken, cfg.GPGPrivateKey, cfg.GPGPassphrase, cfg.SSHPrivateKey, cfg.SSHPassphrase} {
+		logger.Mask(secret)
+	}
+}
 
-	return latest, nil
+// openRepository opens the repository at path.
+func openRepository(path string) (*git.Repository, error) {
+	r, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, &NotRepositoryError{Path: path, Err: err}
+	}
+	return r, nil
 }
 
-func main() {
-	r, _ := git.PlainOpen("./")
+// plannedTag is a tag to create on HEAD, or the version tag HEAD already has if Existing is set.
+type plannedTag struct {
+	Component *Component // nil for whole repository
+	Existing  *VersionTag
+	Tagged    plumbing.Hash // commit of Existing, HEAD or its changelog commit
+	Next      *NextVersion
+	Commits   []*object.Commit // since the previous tag
+	Message   string
+	Floating  []string // floating tags moved to the new tag
+}
+
+// planTags computes the next version of whole repository, or of each component changed since its previous tag.
+func planTags(r *git.Repository, cfg *Config) ([]*plannedTag, error) {
+	if len(cfg.Components) == 0 {
+		p, err := planTag(r, cfg, nil)
+		if err != nil {
+			return nil, err
+		}
+		return []*plannedTag{p}, nil
+	}
+
+	var plans []*plannedTag
+	for i := range cfg.Components {
+		comp := &cfg.Components[i]
+
+		p, err := planTag(r, cfg, comp)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %w", comp.Name, err)
+		}
+
+		if p == nil {
+			Info("Component %s has no changes", comp.Name)
+			continue
+		}
+
+		plans = append(plans, p)
+	}
+	return plans, nil
+}
 
-	version, err := workflowRelease(r)
+// planTag returns nil if component has no commits since its previous tag.
+// A version tag already on HEAD is planned as Existing unless forced, so re-running a workflow doesn't tag twice.
+func planTag(r *git.Repository, cfg *Config, component *Component) (*plannedTag, error) {
+	if !cfg.Force {
+		existing, tagged, err := headVersionTag(r, cfg, cfg.template(component))
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			Info("HEAD is already tagged %s, skip tagging", existing.String())
+			return &plannedTag{Component: component, Existing: existing, Tagged: tagged}, nil
+		}
+	}
+
+	next, err := computeNext(r, cfg, component)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	message, err := summeryCommitMessage(r, version)
+	var paths []string
+	if component != nil {
+		paths = component.Paths
+	}
+
+	commits, err := commitsSince(r, next.Previous, paths)
 	if err != nil {
-		message = fmt.Sprintf("Failed summery commit messages <%s>", err)
+		return nil, err
 	}
 
-	opts := &git.CreateTagOptions{
-		Tagger: &object.Signature{
-			Name:  "whiteblock",
-			Email: "developer@whiteblock.co",
-			When:  kst(),
-		},
-		Message: message,
-		SignKey: nil,
+	if component != nil && next.Previous != nil && len(commits) == 0 {
+		return nil, nil
 	}
-	// Summery commit messages to write description of tag
 
-	c, err := getHeadCommit(r)
+	Info("Bump %s version by %s strategy: %s (%s)", next.Part, next.Strategy, next.Version.String(), next.Reason)
+
+	message, err := summeryCommitMessage(r, next.Previous, paths)
 	if err != nil {
-		panic(err)
+		message = fmt.Sprintf("Failed summery commit messages <%s>", err)
 	}
 
-	err = opts.Validate(r, c.Hash)
+	return &plannedTag{Component: component, Next: next, Commits: commits, Message: message}, nil
+}
+
+// headVersionTag returns the highest version tag of template on the HEAD commit and its commit, or nil if none.
+// Annotated tags are peeled to their commits. With changelog, tags are on the changelog commits made on HEAD,
+// so a tag on a child changelog commit of HEAD, e.g. fetched from the remote on re-run, counts as well.
+func headVersionTag(r *git.Repository, cfg *Config, template *TagTemplate) (*VersionTag, plumbing.Hash, error) {
+	head, err := r.Head()
 	if err != nil {
-		panic(err)
+		return nil, plumbing.ZeroHash, err
 	}
 
-	_, err = r.CreateTag(version.String(), c.Hash, opts)
+	tags, err := r.Tags()
 	if err != nil {
-		panic(err)
+		return nil, plumbing.ZeroHash, err
 	}
 
-	Info("Latest commit: ", c)
-	refSpec := fmt.Sprintf("+refs/tags/%s:refs/tags/%s", version.String(), version.String())
-	err = r.Push(&git.PushOptions{
-		Auth: &http.BasicAuth{
-			Username: "USER_NAME", // this can be anything except an empty string
-			Password: os.Getenv("REPO_TOKEN"),
-		},
-		RefSpecs: []config.RefSpec{config.RefSpec(refSpec)},
+	var latest *VersionTag
+	var tagged plumbing.Hash
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		current, err := parseTag(ref, template)
+		if body, ok := template.trim(ref.Name().Short()); err != nil && ok {
+			// calendar versions of formats which are not semantic versions, e.g. 2026.10
+			current, err = cfg.calverFormat.Parse(body)
+		}
+		if err != nil {
+			return nil
+		}
+		current.ref = ref
+		current.Tag = template.Prefix
+		current.Suffix = template.Suffix
+
+		h, err := tagCommit(r, ref)
+		if err != nil {
+			return err
+		}
+
+		onHead := h == head.Hash()
+		if !onHead && cfg.Changelog {
+			onHead, err = isChangelogCommitOf(r, h, head.Hash())
+			if err != nil {
+				return err
+			}
+		}
+
+		if onHead && (latest == nil || Compare(current, latest) > 0) {
+			latest = current
+			tagged = h
+		}
+		return nil
 	})
 	if err != nil {
-		panic(err)
+		return nil, plumbing.ZeroHash, err
 	}
 
-	Info("Success to bump version: %s", version.String())
-}
-
-// Info should be used to describe the example commands that are about to run.
-func Info(format string, args ...interface{}) {
-	log.Printf("[INFO]"+format, args...)
+	return latest, tagged, nil
 }
 
-// Warning should be used to display a warning
-func Warning(format string, args ...interface{}) {
-	log.Printf("[WARN]"+format, args...)
+// reportDryRun describes the tag which would be created and pushed without dry run.
+func reportDryRun(version *VersionTag, c *object.Commit, message, refSpec string) {
+	Info("Dry run, tag is neither created nor pushed")
+	Info("Tag: %s", version.String())
+	Info("Commit: %s", c.Hash.String())
+	Info("RefSpec: %s", refSpec)
+	Info("Message:")
+	Verbatim(message)
 }
 
-func parseTag(ref *plumbing.Reference) (*VersionTag, error) {
-	version, err := VersionFromString(ref.Name().Short())
+// parseTag parses version tag of the template.
+func parseTag(ref *plumbing.Reference, t *TagTemplate) (*VersionTag, error) {
+	version, err := t.Parse(ref.Name().Short())
 	if err != nil {
 		return nil, err
 	}
@@ -225,71 +522,18 @@ func getHeadCommit(r *git.Repository) (*object.Commit, error) {
 	return cIter.Next()
 }
 
-func summeryCommitMessage(r *git.Repository, prevLatestTag *VersionTag) (string, error) {
-	head, err := r.Head()
-	if err != nil {
-		Warning("Failed to get head reference: %s", err.Error())
-		return "", err
-	}
-
-	cIter, err := r.Log(&git.LogOptions{From: head.Hash()})
+func summeryCommitMessage(r *git.Repository, prevLatestTag *VersionTag, paths []string) (string, error) {
+	commits, err := commitsSince(r, prevLatestTag, paths)
 	if err != nil {
-		Warning("Failed to iterate git log")
 		return "", err
 	}
 
-	var messages []string
-	commit, err := cIter.Next()
-	if err != nil {
-		Warning("Failed to get head commit: %s", err.Error())
-		return "", err
-	}
-
-	h, err := r.ResolveRevision(plumbing.Revision(prevLatestTag.ref.Hash().String()))
-	if err != nil {
-		Warning("Failed to get latest tag: %s", err.Error())
-		return "", err
-	}
-	obj, err := r.Object(plumbing.AnyObject, *h)
-	prevTagCommit := obj.(*object.Commit)
-	for commit != nil && commit.Hash != prevTagCommit.Hash {
-		messages = append(messages, commit.Message)
-		commit, err = cIter.Next()
-		if err != nil {
-			break
-		}
-	}
-
 	summery := ""
-	for i := range messages {
-		summery += "* " + messages[i]
+	for i := range commits {
+		summery += "* " + commits[i].Message
 	}
 	if summery == "" {
 		summery = "Nothing new, just for tagging."
 	}
 	return summery, nil
 }
-
-func isNewerVersion(old, new *VersionTag) bool {
-	if old.Major > new.Major {
-		return false
-	} else if old.Major == new.Major {
-		if old.Minor > new.Minor {
-			return false
-		} else if old.Minor == new.Minor {
-			if old.Patch > new.Patch {
-				return false
-			} else if old.Patch == new.Patch {
-				if old.Build > new.Build {
-					return false
-				}
-			}
-		}
-	}
-	return true
-}
-
-func kst() time.Time {
-	loc, _ := time.LoadLocation("Asia/Seoul")
-	return time.Now().In(loc)
-}
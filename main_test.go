@@ -1,8 +1,16 @@
 package main
 
 import (
-	"github.com/stretchr/testify/assert"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestVersionTag_String(t *testing.T) {
@@ -94,3 +102,237 @@ func TestVersionFromString(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, v123b1, v_v123b1.String())
 }
+
+func TestVersionTag_Increment(t *testing.T) {
+	v := &VersionTag{Tag: "v", Major: 1, Minor: 2, Patch: 3, Build: "b1"}
+	v.Increment(BumpPatch)
+	assert.Equal(t, "v1.2.4", v.String())
+
+	v.Increment(BumpMinor)
+	assert.Equal(t, "v1.3.0", v.String())
+
+	v.Increment(BumpMajor)
+	assert.Equal(t, "v2.0.0", v.String())
+
+	// pre-releases are released rather than skipped
+	cases := map[string]map[Bump]string{
+		"v1.2.3-rc.10": {BumpPatch: "v1.2.3", BumpMinor: "v1.3.0", BumpMajor: "v2.0.0"},
+		"v1.3.0-rc.1":  {BumpPatch: "v1.3.0", BumpMinor: "v1.3.0", BumpMajor: "v2.0.0"},
+		"v2.0.0-rc.1":  {BumpPatch: "v2.0.0", BumpMinor: "v2.0.0", BumpMajor: "v2.0.0"},
+	}
+	for version, bumps := range cases {
+		for b, expected := range bumps {
+			v, err := VersionFromString(version)
+			assert.NoError(t, err)
+			v.Increment(b)
+			assert.Equal(t, expected, v.String(), "%s by %s", version, b)
+		}
+	}
+}
+
+func TestIncrementPre(t *testing.T) {
+	assert.Equal(t, "0", incrementPre(""))
+	assert.Equal(t, "1", incrementPre("0"))
+	assert.Equal(t, "build.10", incrementPre("build.9"))
+	assert.Equal(t, "alpha.1", incrementPre("alpha"))
+}
+
+func TestCompare(t *testing.T) {
+	// ascending order from https://semver.org/#spec-item-11
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+
+	for i := range ordered {
+		for j := range ordered {
+			a, err := VersionFromString(ordered[i])
+			assert.NoError(t, err)
+			b, err := VersionFromString(ordered[j])
+			assert.NoError(t, err)
+
+			assert.Equal(t, compareInt(i, j), Compare(a, b), "%s <=> %s", ordered[i], ordered[j])
+		}
+	}
+
+	a, _ := VersionFromString("v1.2.3+b2")
+	b, _ := VersionFromString("1.2.3+b1")
+	assert.Equal(t, 0, Compare(a, b))
+
+	a, _ = VersionFromString("1.2.3-rc.10")
+	b, _ = VersionFromString("1.2.3-rc.9")
+	assert.Equal(t, 1, Compare(a, b))
+}
+
+func TestPlanTags_HeadTagged(t *testing.T) {
+	cfg := testConfig(t, func(cfg *Config) { cfg.Strategy = "trunk" })
+
+	r := newTestRepo(t, "main")
+	h := r.commit("feat: first")
+	r.tag("v1.0.0", h)
+	h = r.commit("fix: second")
+	r.annotatedTag("v1.0.1", h)
+	r.tag("other-tag", h)
+
+	plans, err := planTags(r.Repository, cfg)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Nil(t, plans[0].Next)
+		assert.Equal(t, "v1.0.1", plans[0].Existing.String())
+	}
+
+	// the highest one of lightweight and annotated tags
+	r.tag("v1.0.2", h)
+	plans, err = planTags(r.Repository, cfg)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Equal(t, "v1.0.2", plans[0].Existing.String())
+	}
+
+	cfg.Force = true
+	plans, err = planTags(r.Repository, cfg)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Nil(t, plans[0].Existing)
+		assert.Equal(t, "v1.0.3", plans[0].Next.Version.String())
+	}
+
+	cfg.Force = false
+	r.commit("fix: third")
+	plans, err = planTags(r.Repository, cfg)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Nil(t, plans[0].Existing)
+		assert.Equal(t, "v1.0.3", plans[0].Next.Version.String())
+	}
+}
+
+func TestTagRepository_DryRun(t *testing.T) {
+	// no release is created in dry run
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request in dry run: %s %s", r.Method, r.URL.Path)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	summary := filepath.Join(t.TempDir(), "summary")
+	setEnv(t, "GITHUB_STEP_SUMMARY", summary)
+
+	r := newTestRepo(t, "main")
+	r.tag("v1.0.0", r.commit("chore: first"))
+	head := r.commitFile("main.go", "feat: second")
+
+	w, err := r.Worktree()
+	assert.NoError(t, err)
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.RepoPath = w.Filesystem.Root()
+		cfg.Strategy = "trunk"
+		cfg.Fetch = false
+		cfg.DryRun = true
+		cfg.Changelog = true
+		cfg.Release = true
+		cfg.RepoToken = "secret"
+		cfg.GithubRepository = "owner/repo"
+		cfg.GithubAPIURL = server.URL
+	})
+
+	outputs := NewOutputs()
+	assert.NoError(t, tagRepository(cfg, outputs))
+
+	assert.Equal(t, "v1.1.0", outputs.Get("new_tag"))
+	assert.Equal(t, "v1.1.0", outputs.Get("tag"))
+	assert.Equal(t, "minor", outputs.Get("part"))
+	assert.Equal(t, "v1.0.0", outputs.Get("previous_tag"))
+	assert.Equal(t, head.String(), outputs.Get("commit"))
+	assert.Equal(t, "", outputs.Get("release_url"))
+
+	// neither the tag nor the changelog commit is created
+	_, err = r.Tag("v1.1.0")
+	assert.Equal(t, git.ErrTagNotFound, err)
+	ref, err := r.Head()
+	assert.NoError(t, err)
+	assert.Equal(t, head, ref.Hash())
+	_, err = os.Stat(filepath.Join(cfg.RepoPath, cfg.ChangelogFile))
+	assert.True(t, os.IsNotExist(err))
+
+	b, err := os.ReadFile(summary)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "Dry run")
+}
+
+func TestTagRepository_RerunRelease(t *testing.T) {
+	var created []*Release
+	existing := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/repos/owner/repo/releases/tags/"):
+			tag := strings.TrimPrefix(r.URL.Path, "/repos/owner/repo/releases/tags/")
+			if !existing[tag] {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"message":"Not Found"}`))
+				return
+			}
+			assert.NoError(t, json.NewEncoder(w).Encode(&Release{ID: 1, TagName: tag, HTMLURL: "https://github.com/owner/repo/releases/tag/" + tag}))
+		case r.Method == http.MethodPost && r.URL.Path == "/repos/owner/repo/releases":
+			release := &Release{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(release))
+			created = append(created, release)
+			existing[release.TagName] = true
+
+			release.ID = 1
+			release.HTMLURL = "https://github.com/owner/repo/releases/tag/" + release.TagName
+			w.WriteHeader(http.StatusCreated)
+			assert.NoError(t, json.NewEncoder(w).Encode(release))
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	// the tag was pushed, but creating the release failed
+	r := newTestRepo(t, "main")
+	r.tag("v1.0.0", r.commit("chore: first"))
+	r.commit("feat: second")
+	head := r.commit("fix: third")
+	r.tag("v1.1.0", head)
+
+	w, err := r.Worktree()
+	assert.NoError(t, err)
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.RepoPath = w.Filesystem.Root()
+		cfg.Strategy = "trunk"
+		cfg.Fetch = false
+		cfg.Release = true
+		cfg.RepoToken = "secret"
+		cfg.GithubRepository = "owner/repo"
+		cfg.GithubAPIURL = server.URL
+	})
+
+	outputs := NewOutputs()
+	assert.NoError(t, tagRepository(cfg, outputs))
+	if assert.Len(t, created, 1) {
+		assert.Equal(t, "v1.1.0", created[0].TagName)
+		assert.Contains(t, created[0].Body, "second")
+		assert.Contains(t, created[0].Body, "third")
+	}
+	assert.Equal(t, "", outputs.Get("new_tag"))
+	assert.Equal(t, "v1.1.0", outputs.Get("tag"))
+	assert.Equal(t, head.String(), outputs.Get("commit"))
+	assert.Equal(t, "https://github.com/owner/repo/releases/tag/v1.1.0", outputs.Get("release_url"))
+
+	// the release is created once
+	outputs = NewOutputs()
+	assert.NoError(t, tagRepository(cfg, outputs))
+	assert.Len(t, created, 1)
+	assert.Equal(t, "https://github.com/owner/repo/releases/tag/v1.1.0", outputs.Get("release_url"))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Outputs are values passed to the following steps of workflow through the file of GITHUB_OUTPUT.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#setting-an-output-parameter
+type Outputs struct {
+	names  []string
+	values map[string]string
+}
+
+func NewOutputs() *Outputs {
+	return &Outputs{values: map[string]string{}}
+}
+
+// Set sets the value of output. The order of first set is kept when written.
+func (o *Outputs) Set(name, value string) {
+	if _, ok := o.values[name]; !ok {
+		o.names = append(o.names, name)
+	}
+	o.values[name] = value
+}
+
+// Get returns the value of output, or empty string if it is not set.
+func (o *Outputs) Get(name string) string {
+	return o.values[name]
+}
+
+// SetVersion sets the outputs describing the new version tagged on commit.
+func (o *Outputs) SetVersion(next *NextVersion, commit plumbing.Hash) {
+	o.setVersion("", next, commit)
+}
+
+// SetComponentVersion sets the same outputs as SetVersion, prefixed with the name of component, e.g. api_new_tag.
+func (o *Outputs) SetComponentVersion(component string, next *NextVersion, commit plumbing.Hash) {
+	o.setVersion(component+"_", next, commit)
+}
+
+// SetTagged sets the outputs of version which HEAD is already tagged with. new_tag is empty, as nothing is created.
+func (o *Outputs) SetTagged(version *VersionTag, commit plumbing.Hash) {
+	o.setTagged("", version, commit)
+}
+
+// SetComponentTagged sets the same outputs as SetTagged, prefixed with the name of component.
+func (o *Outputs) SetComponentTagged(component string, version *VersionTag, commit plumbing.Hash) {
+	o.setTagged(component+"_", version, commit)
+}
+
+func (o *Outputs) setTagged(prefix string, version *VersionTag, commit plumbing.Hash) {
+	o.setVersion(prefix, &NextVersion{Version: version, Part: BumpNone}, commit)
+	o.Set(prefix+"new_tag", "")
+}
+
+func (o *Outputs) setVersion(prefix string, next *NextVersion, commit plumbing.Hash) {
+	version := next.Version
+	o.Set(prefix+"new_tag", version.String())
+	o.Set(prefix+"tag", version.String())
+	o.Set(prefix+"part", next.Part.String())
+	o.Set(prefix+"major", strconv.Itoa(version.Major))
+	o.Set(prefix+"minor", strconv.Itoa(version.Minor))
+	o.Set(prefix+"patch", strconv.Itoa(version.Patch))
+	o.Set(prefix+"prerelease", version.Pre)
+	o.Set(prefix+"build", version.Build)
+	o.Set(prefix+"previous_tag", previousTag(next.Previous))
+	o.Set(prefix+"commit", commit.String())
+}
+
+// Write writes outputs in the format of GITHUB_OUTPUT.
+// Values of multiple lines are written between random delimiters, so they can't end the value early.
+func (o *Outputs) Write(w io.Writer) error {
+	for _, name := range o.names {
+		value := o.values[name]
+
+		if !strings.ContainsAny(value, "\r\n") {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", name, value); err != nil {
+				return err
+			}
+			continue
+		}
+
+		delimiter, err := randomDelimiter("ghadelimiter_", value)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Save appends outputs to the file of GITHUB_OUTPUT. Outputs are only logged when it is not set, e.g. run locally.
+func (o *Outputs) Save() error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		Warning("GITHUB_OUTPUT is not set, outputs are not saved")
+		for _, name := range o.names {
+			Info("Output %s: %s", name, o.values[name])
+		}
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if err := o.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// randomDelimiter returns prefix followed by random hex, which value doesn't contain, so value can't end it early.
+func randomDelimiter(prefix, value string) (string, error) {
+	for {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+
+		delimiter := prefix + hex.EncodeToString(b)
+		if !strings.Contains(value, delimiter) {
+			return delimiter, nil
+		}
+	}
+}
+
+// previousTag returns name of the tag which version is bumped from, or empty string for the first version.
+func previousTag(previous *VersionTag) string {
+	if previous == nil || previous.ref == nil {
+		return ""
+	}
+	return previous.ref.Name().Short()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputs_Write(t *testing.T) {
+	o := NewOutputs()
+	o.Set("tag", "v1.2.3")
+	o.Set("notes", "* fix: one\n* feat: two")
+	o.Set("tag", "v1.2.4")
+
+	buf := &bytes.Buffer{}
+	assert.NoError(t, o.Write(buf))
+
+	re := regexp.MustCompile("^tag=v1\\.2\\.4\nnotes<<(ghadelimiter_[0-9a-f]+)\n\\* fix: one\n\\* feat: two\n(ghadelimiter_[0-9a-f]+)\n$")
+	matches := re.FindStringSubmatch(buf.String())
+	if assert.NotNil(t, matches, buf.String()) {
+		assert.Equal(t, matches[1], matches[2])
+	}
+}
+
+func TestOutputs_SetVersion(t *testing.T) {
+	previous, err := VersionFromString("v1.2.3")
+	assert.NoError(t, err)
+	previous.ref = plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.2.3"), plumbing.ZeroHash)
+
+	next := &NextVersion{
+		Version:  &VersionTag{Tag: "v", Major: 1, Minor: 3, Patch: 0},
+		Previous: previous,
+		Part:     BumpMinor,
+	}
+
+	o := NewOutputs()
+	o.SetVersion(next, plumbing.NewHash("0123456789012345678901234567890123456789"))
+
+	assert.Equal(t, "v1.3.0", o.Get("new_tag"))
+	assert.Equal(t, "v1.3.0", o.Get("tag"))
+	assert.Equal(t, "minor", o.Get("part"))
+	assert.Equal(t, "1", o.Get("major"))
+	assert.Equal(t, "3", o.Get("minor"))
+	assert.Equal(t, "0", o.Get("patch"))
+	assert.Equal(t, "", o.Get("prerelease"))
+	assert.Equal(t, "v1.2.3", o.Get("previous_tag"))
+	assert.Equal(t, "0123456789012345678901234567890123456789", o.Get("commit"))
+}
+
+func TestOutputs_Save(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "output")
+	assert.NoError(t, os.WriteFile(path, []byte("other=1\n"), 0644))
+	os.Setenv("GITHUB_OUTPUT", path)
+	defer os.Unsetenv("GITHUB_OUTPUT")
+
+	o := NewOutputs()
+	o.Set("tag", "v1.2.3")
+	assert.NoError(t, o.Save())
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "other=1\ntag=v1.2.3\n", string(b))
+}
+
+func TestOutputs_SetTagged(t *testing.T) {
+	o := NewOutputs()
+	o.SetComponentTagged("api", &VersionTag{Tag: "api/v", Major: 1, Minor: 4, Patch: 2}, plumbing.NewHash("0123456789012345678901234567890123456789"))
+
+	assert.Equal(t, "", o.Get("api_new_tag"))
+	assert.Equal(t, "api/v1.4.2", o.Get("api_tag"))
+	assert.Equal(t, "none", o.Get("api_part"))
+	assert.Equal(t, "2", o.Get("api_patch"))
+	assert.Equal(t, "0123456789012345678901234567890123456789", o.Get("api_commit"))
+}
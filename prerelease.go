@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// defaultPrereleaseChannels are the channels of pre-releases in order, from the earliest.
+var defaultPrereleaseChannels = []string{"alpha", "beta", "rc"}
+
+// channelRegex matches non-numeric identifiers of pre-release, which channels are named by.
+var channelRegex = regexp.MustCompile("^[0-9A-Za-z-]*[A-Za-z-][0-9A-Za-z-]*$")
+
+// parseChannelPre returns the channel and counter of pre-release of form <channel>.<counter>, e.g. rc.2,
+// or false if pre isn't of the form. A channel without counter, e.g. beta, has counter 0.
+func parseChannelPre(pre string) (string, int, bool) {
+	ids := strings.Split(pre, ".")
+	if !channelRegex.MatchString(ids[0]) {
+		return "", 0, false
+	}
+
+	switch len(ids) {
+	case 1:
+		return ids[0], 0, true
+	case 2:
+		n, err := strconv.Atoi(ids[1])
+		if err != nil || n < 0 {
+			return "", 0, false
+		}
+		return ids[0], n, true
+	default:
+		return "", 0, false
+	}
+}
+
+// applyChannel makes the version computed by strategy a pre-release in channel, e.g. 1.5.0-beta.3, counting up
+// from the highest pre-release of the same version in channel, starting at .1. Channels are ordered by
+// prerelease_channels, and pre-releases never move backwards, e.g. to beta after rc, or after the release itself.
+func applyChannel(ctx *StrategyContext, next *NextVersion, channel string) (*NextVersion, error) {
+	version := *next.Version
+	version.ref = nil
+	version.Pre = ""
+	version.Build = ""
+
+	order := indexOf(ctx.Config.PrereleaseChannels, channel)
+	counter := 0
+	var latest *VersionTag // the highest pre-release of version
+	for _, t := range ctx.Tags {
+		if t.Major != version.Major || t.Minor != version.Minor || t.Patch != version.Patch {
+			continue
+		}
+		if isRelease(t) {
+			return nil, &ChannelOrderError{Version: version.String(), Channel: channel, Existing: t.String()}
+		}
+
+		if latest == nil || Compare(t, latest) > 0 {
+			latest = t
+		}
+
+		c, n, ok := parseChannelPre(t.Pre)
+		if !ok {
+			continue
+		}
+		if indexOf(ctx.Config.PrereleaseChannels, c) > order {
+			return nil, &ChannelOrderError{Version: version.String(), Channel: channel, Existing: t.String()}
+		}
+		if c == channel && n > counter {
+			counter = n
+		}
+	}
+
+	version.Pre = fmt.Sprintf("%s.%d", channel, counter+1)
+
+	if latest == nil {
+		return &NextVersion{
+			Version:  &version,
+			Previous: next.Previous,
+			Part:     next.Part,
+			Reason:   fmt.Sprintf("%s, first %s pre-release", next.Reason, channel),
+		}, nil
+	}
+
+	return &NextVersion{
+		Version:  &version,
+		Previous: latest,
+		Part:     BumpPrerelease,
+		Reason:   fmt.Sprintf("%s pre-release after %s", channel, latest.String()),
+	}, nil
+}
+
+func indexOf(items []string, item string) int {
+	for i, v := range items {
+		if v == item {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChannelPre(t *testing.T) {
+	cases := map[string]struct {
+		channel string
+		counter int
+		ok      bool
+	}{
+		"rc.2":       {"rc", 2, true},
+		"beta":       {"beta", 0, true},
+		"pre-1.10":   {"pre-1", 10, true},
+		"0":          {"", 0, false},
+		"alpha.x":    {"", 0, false},
+		"alpha.1.2":  {"", 0, false},
+		"1.alpha":    {"", 0, false},
+		"alpha.beta": {"", 0, false},
+	}
+	for pre, expected := range cases {
+		channel, counter, ok := parseChannelPre(pre)
+		assert.Equal(t, expected.ok, ok, pre)
+		assert.Equal(t, expected.channel, channel, pre)
+		assert.Equal(t, expected.counter, counter, pre)
+	}
+}
+
+func TestApplyChannel(t *testing.T) {
+	channel := func(name string) *Config {
+		return testConfig(t, func(cfg *Config) {
+			cfg.Strategy = "trunk"
+			cfg.Prerelease = name
+		})
+	}
+
+	r := newTestRepo(t, "main")
+	r.tag("v1.4.2", r.commit("fix: release"))
+	r.commit("feat: first")
+
+	next := nextVersion(t, r, channel("alpha"))
+	assert.Equal(t, "v1.5.0-alpha.1", next.Version.String())
+	assert.Equal(t, BumpMinor, next.Part)
+	assert.Equal(t, "v1.4.2", previousTag(next.Previous))
+
+	r.tag("v1.5.0-alpha.1", r.commit("fix: second"))
+	r.commit("fix: third")
+
+	next = nextVersion(t, r, channel("alpha"))
+	assert.Equal(t, "v1.5.0-alpha.2", next.Version.String())
+
+	// counters are of the target version, compared numerically
+	r = newTestRepo(t, "main")
+	r.tag("v1.4.2", r.commit("fix: release"))
+	r.tag("v1.5.0-alpha.9", r.commit("feat: first"))
+	r.tag("v1.5.0-alpha.10", r.commit("fix: second"))
+	r.tag("v1.6.0-alpha.30", r.commit("fix: other version"))
+	r.commit("fix: third")
+
+	next = nextVersion(t, r, channel("alpha"))
+	assert.Equal(t, "v1.5.0-alpha.11", next.Version.String())
+	assert.Equal(t, BumpPrerelease, next.Part)
+	assert.Equal(t, "v1.5.0-alpha.10", previousTag(next.Previous))
+
+	// a later channel starts at .1
+	next = nextVersion(t, r, channel("rc"))
+	assert.Equal(t, "v1.5.0-rc.1", next.Version.String())
+	assert.Equal(t, "rc pre-release after v1.5.0-alpha.10", next.Reason)
+
+	// never backwards in channel order
+	r.tag("v1.5.0-rc.1", r.commit("fix: fourth"))
+	r.commit("fix: fifth")
+
+	_, err := computeNext(r.Repository, channel("beta"), nil)
+	if assert.IsType(t, &ChannelOrderError{}, err) {
+		assert.Equal(t, "v1.5.0-rc.1", err.(*ChannelOrderError).Existing)
+	}
+
+	next = nextVersion(t, r, channel("rc"))
+	assert.Equal(t, "v1.5.0-rc.2", next.Version.String())
+
+	// on release branches the pre-release of the line is counted up rather than its next patch
+	branch := testConfig(t, func(cfg *Config) { cfg.Prerelease = "rc" })
+
+	r = newTestRepo(t, "release/1.5")
+	r.tag("v1.4.2", r.commit("fix: release"))
+	r.tag("v1.5.0-rc.1", r.commit("feat: first"))
+	r.commit("feat: second")
+
+	next = nextVersion(t, r, branch)
+	assert.Equal(t, "release-branch", next.Strategy)
+	assert.Equal(t, "v1.5.0-rc.2", next.Version.String())
+	assert.Equal(t, BumpPrerelease, next.Part)
+	assert.Equal(t, "v1.5.0-rc.1", previousTag(next.Previous))
+
+	r.tag("v1.5.0", r.commit("fix: third"))
+	r.commit("fix: fourth")
+
+	next = nextVersion(t, r, branch)
+	assert.Equal(t, "v1.5.1-rc.1", next.Version.String())
+	assert.Equal(t, "v1.5.0", previousTag(next.Previous))
+}
+
+func TestApplyChannel_BuildNumber(t *testing.T) {
+	cfg := testConfig(t, func(cfg *Config) { cfg.Prerelease = "beta" })
+
+	r := newTestRepo(t, "develop")
+	r.tag("v0.1.0", r.commit("initial"))
+	r.commit("second")
+
+	next := nextVersion(t, r, cfg)
+	assert.Equal(t, "build-number", next.Strategy)
+	assert.Equal(t, "v0.1.1-beta.1", next.Version.String())
+
+	r.tag("v0.1.1-beta.1", r.commit("third"))
+	r.commit("fourth")
+
+	next = nextVersion(t, r, cfg)
+	assert.Equal(t, "v0.1.1-beta.2", next.Version.String())
+}
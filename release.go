@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Release is a GitHub release of tag.
+// See https://docs.github.com/en/rest/releases/releases#create-a-release
+type Release struct {
+	ID         int64  `json:"id,omitempty"`
+	TagName    string `json:"tag_name"`
+	Name       string `json:"name"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	HTMLURL    string `json:"html_url,omitempty"`
+}
+
+// ReleasePublisher creates GitHub releases through REST API.
+type ReleasePublisher struct {
+	APIURL     string // e.g. https://api.github.com, or https://HOSTNAME/api/v3 for Enterprise
+	Repository string // owner/repo
+	Token      string
+	Client     *http.Client
+}
+
+func NewReleasePublisher(cfg *Config) *ReleasePublisher {
+	return &ReleasePublisher{
+		APIURL:     cfg.GithubAPIURL,
+		Repository: cfg.GithubRepository,
+		Token:      cfg.RepoToken,
+		Client:     &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// NewRelease returns the release of version with notes as body, which is prerelease if version has pre-release.
+func NewRelease(version *VersionTag, notes string, draft bool) *Release {
+	return &Release{
+		TagName:    version.String(),
+		Name:       version.String(),
+		Body:       notes,
+		Draft:      draft,
+		Prerelease: version.Pre != "",
+	}
+}
+
+// Create creates release and returns the created one.
+func (p *ReleasePublisher) Create(release *Release) (*Release, error) {
+	body, err := json.Marshal(release)
+	if err != nil {
+		return nil, err
+	}
+
+	status, b, err := p.request(http.MethodPost, "/releases", body)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusCreated {
+		return nil, fmt.Errorf("failed to create release %s: %s", release.TagName, apiError(status, b))
+	}
+
+	created := &Release{}
+	if err := json.Unmarshal(b, created); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// Get returns the published release of tag, or nil if none. Draft releases are not found by tag.
+// See https://docs.github.com/en/rest/releases/releases#get-a-release-by-tag-name
+func (p *ReleasePublisher) Get(tag string) (*Release, error) {
+	// slashes of component tags, e.g. api/v1.4.2, are kept
+	segments := strings.Split(tag, "/")
+	for i := range segments {
+		segments[i] = url.PathEscape(segments[i])
+	}
+
+	status, b, err := p.request(http.MethodGet, "/releases/tags/"+strings.Join(segments, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to get release %s: %s", tag, apiError(status, b))
+	}
+
+	release := &Release{}
+	if err := json.Unmarshal(b, release); err != nil {
+		return nil, err
+	}
+	return release, nil
+}
+
+// request sends body, which can be nil, to path under the repository, and returns the status code and body of response.
+func (p *ReleasePublisher) request(method, path string, body []byte) (int, []byte, error) {
+	u := fmt.Sprintf("%s/repos/%s%s", strings.TrimRight(p.APIURL, "/"), p.Repository, path)
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+p.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return res.StatusCode, b, nil
+}
+
+// apiError describes the failed response of status with the message of body, e.g. 422 Unprocessable Entity: Validation Failed.
+func apiError(status int, body []byte) string {
+	var e struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(body, &e) != nil || e.Message == "" {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	return fmt.Sprintf("%d %s: %s", status, http.StatusText(status), e.Message)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReleasePublisher_Create(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v3/repos/whiteblockco/github-tag-action/releases", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		release := &Release{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(release))
+		assert.Equal(t, "v1.3.0-rc.1", release.TagName)
+		assert.Equal(t, "notes", release.Body)
+		assert.True(t, release.Prerelease)
+		assert.True(t, release.Draft)
+
+		release.ID = 1
+		release.HTMLURL = "https://github.example.com/whiteblockco/github-tag-action/releases/tag/v1.3.0-rc.1"
+		w.WriteHeader(http.StatusCreated)
+		assert.NoError(t, json.NewEncoder(w).Encode(release))
+	}))
+	defer server.Close()
+
+	p := &ReleasePublisher{
+		APIURL:     server.URL + "/api/v3/",
+		Repository: "whiteblockco/github-tag-action",
+		Token:      "secret",
+		Client:     server.Client(),
+	}
+
+	version, err := VersionFromString("v1.3.0-rc.1")
+	assert.NoError(t, err)
+
+	created, err := p.Create(NewRelease(version, "notes", true))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), created.ID)
+	assert.Equal(t, "https://github.example.com/whiteblockco/github-tag-action/releases/tag/v1.3.0-rc.1", created.HTMLURL)
+}
+
+func TestReleasePublisher_CreateFailed(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(`{"message":"Validation Failed"}`))
+	}))
+	defer server.Close()
+
+	p := &ReleasePublisher{APIURL: server.URL, Repository: "o/r", Token: "secret", Client: server.Client()}
+
+	_, err := p.Create(NewRelease(&VersionTag{Tag: "v", Major: 1}, "", false))
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "422")
+		assert.Contains(t, err.Error(), "Validation Failed")
+	}
+}
+
+func TestReleasePublisher_Get(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/repos/o/r/releases/tags/api/v1.0.0":
+			assert.NoError(t, json.NewEncoder(w).Encode(&Release{ID: 1, TagName: "api/v1.0.0", HTMLURL: "https://github.com/o/r/releases/tag/api/v1.0.0"}))
+		case "/repos/o/r/releases/tags/v2.0.0":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"Not Found"}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"message":"Server Error"}`))
+		}
+	}))
+	defer server.Close()
+
+	p := &ReleasePublisher{APIURL: server.URL, Repository: "o/r", Token: "secret", Client: server.Client()}
+
+	release, err := p.Get("api/v1.0.0")
+	assert.NoError(t, err)
+	if assert.NotNil(t, release) {
+		assert.Equal(t, "https://github.com/o/r/releases/tag/api/v1.0.0", release.HTMLURL)
+	}
+
+	release, err = p.Get("v2.0.0")
+	assert.NoError(t, err)
+	assert.Nil(t, release)
+
+	_, err = p.Get("v3.0.0")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "500")
+		assert.Contains(t, err.Error(), "Server Error")
+	}
+}
+
+func TestNewRelease(t *testing.T) {
+	release := NewRelease(&VersionTag{Tag: "v", Major: 1}, "notes", false)
+	assert.Equal(t, "v1.0.0", release.TagName)
+	assert.False(t, release.Prerelease)
+	assert.False(t, release.Draft)
+}
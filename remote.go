@@ -0,0 +1,442 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/capability"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp/sideband"
+	"github.com/go-git/go-git/v5/plumbing/revlist"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// Authentication methods of the remote.
+const (
+	AuthToken    = "token"     // HTTP basic auth with repo_token
+	AuthSSHKey   = "ssh-key"   // SSH with ssh_private_key
+	AuthSSHAgent = "ssh-agent" // SSH with keys of the agent at SSH_AUTH_SOCK
+	AuthNone     = "none"
+)
+
+var authMethods = []string{AuthToken, AuthSSHKey, AuthSSHAgent, AuthNone}
+
+// defaultAuth is the method used when auth is not configured, which is chosen by the given credentials.
+func (c *Config) defaultAuth() string {
+	switch {
+	case c.RepoToken != "":
+		return AuthToken
+	case c.SSHPrivateKey != "":
+		return AuthSSHKey
+	default:
+		return AuthNone
+	}
+}
+
+// newAuth returns the auth method of the remote, nil for no auth.
+func newAuth(cfg *Config) (transport.AuthMethod, error) {
+	switch cfg.Auth {
+	case AuthToken:
+		return &http.BasicAuth{
+			Username: "USER_NAME", // this can be anything except an empty string
+			Password: cfg.RepoToken,
+		}, nil
+	case AuthSSHKey:
+		keys, err := ssh.NewPublicKeys(cfg.SSHUser, []byte(cfg.SSHPrivateKey), cfg.SSHPassphrase)
+		if err != nil {
+			return nil, &AuthError{Remote: cfg.Remote, Auth: cfg.Auth, Err: fmt.Errorf("invalid ssh private key: %w", err)}
+		}
+		keys.HostKeyCallback, err = knownHostsCallback(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return keys, nil
+	case AuthSSHAgent:
+		agent, err := ssh.NewSSHAgentAuth(cfg.SSHUser)
+		if err != nil {
+			return nil, &AuthError{Remote: cfg.Remote, Auth: cfg.Auth, Err: fmt.Errorf("failed to connect ssh-agent: %w", err)}
+		}
+		agent.HostKeyCallback, err = knownHostsCallback(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return agent, nil
+	default:
+		return nil, nil
+	}
+}
+
+// knownHostsCallback verifies host keys of SSH remotes against ssh_known_hosts, ssh_known_hosts_file,
+// or the default files of SSH_KNOWN_HOSTS, ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts.
+// Unknown hosts are always rejected.
+func knownHostsCallback(cfg *Config) (gossh.HostKeyCallback, error) {
+	var files []string
+	if cfg.SSHKnownHostsFile != "" {
+		files = append(files, cfg.SSHKnownHostsFile)
+	}
+
+	if cfg.SSHKnownHosts != "" {
+		// knownhosts reads only files, which are loaded at once, so the temporary file can be removed right after
+		f, err := os.CreateTemp("", "known_hosts")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(f.Name())
+
+		_, err = f.WriteString(cfg.SSHKnownHosts + "\n")
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f.Name())
+	}
+
+	cb, err := ssh.NewKnownHostsCallback(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known hosts: %w", err)
+	}
+	return cb, nil
+}
+
+// openRemote returns the configured remote of repository, or an anonymous one of remote_url if set.
+func openRemote(r *git.Repository, cfg *Config) (*git.Remote, error) {
+	if cfg.RemoteURL != "" {
+		return git.NewRemote(r.Storer, &config.RemoteConfig{
+			Name: cfg.Remote,
+			URLs: []string{cfg.RemoteURL},
+		}), nil
+	}
+
+	remote, err := r.Remote(cfg.Remote)
+	if err != nil {
+		return nil, fmt.Errorf("remote %s: %w", cfg.Remote, err)
+	}
+	return remote, nil
+}
+
+// push pushes refSpecs to the configured remote with the configured auth.
+func push(r *git.Repository, cfg *Config, refSpecs []config.RefSpec) error {
+	remote, err := openRemote(r, cfg)
+	if err != nil {
+		return err
+	}
+
+	auth, err := newAuth(cfg)
+	if err != nil {
+		return err
+	}
+
+	err = remote.Push(&git.PushOptions{
+		RemoteName: cfg.Remote,
+		Auth:       auth,
+		RefSpecs:   refSpecs,
+	})
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+	return authError(cfg, err)
+}
+
+// pushAtomic pushes refSpecs in one receive-pack request with the atomic capability, like git push --atomic,
+// so the remote updates all refs or none. go-git doesn't request atomic pushes, so the request is made here.
+// As in push, remote refs are overwritten only by force refspecs, and branches only by fast-forwards.
+func pushAtomic(r *git.Repository, cfg *Config, refSpecs []config.RefSpec) (err error) {
+	remote, err := openRemote(r, cfg)
+	if err != nil {
+		return err
+	}
+
+	auth, err := newAuth(cfg)
+	if err != nil {
+		return err
+	}
+
+	ep, err := transport.NewEndpoint(remote.Config().URLs[0])
+	if err != nil {
+		return err
+	}
+
+	c, err := client.NewClient(ep)
+	if err != nil {
+		return err
+	}
+
+	s, err := c.NewReceivePackSession(ep, auth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := s.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	ar, err := s.AdvertisedReferences()
+	if err != nil {
+		return authError(cfg, err)
+	}
+	if !ar.Capabilities.Supports(capability.Atomic) {
+		return fmt.Errorf("remote %s doesn't support atomic push", cfg.Remote)
+	}
+
+	remoteRefs, err := ar.AllReferences()
+	if err != nil {
+		return err
+	}
+
+	req := packp.NewReferenceUpdateRequestFromCapabilities(ar.Capabilities)
+	if err := req.Capabilities.Set(capability.Atomic); err != nil {
+		return err
+	}
+
+	var wants []plumbing.Hash
+	for _, rs := range refSpecs {
+		local, err := r.Reference(plumbing.ReferenceName(rs.Src()), true)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rs.Src(), err)
+		}
+
+		cmd := &packp.Command{Name: rs.Dst(""), New: local.Hash()}
+		if ref, err := remoteRefs.Reference(cmd.Name); err == nil {
+			cmd.Old = ref.Hash()
+		} else if err != plumbing.ErrReferenceNotFound {
+			return err
+		}
+
+		if cmd.Old == cmd.New {
+			continue
+		}
+		if cmd.Old != plumbing.ZeroHash && !rs.IsForceUpdate() {
+			if err := checkFastForward(r, cmd); err != nil {
+				return err
+			}
+		}
+
+		req.Commands = append(req.Commands, cmd)
+		wants = append(wants, cmd.New)
+	}
+
+	if len(req.Commands) == 0 {
+		return nil
+	}
+
+	haves, err := referenceHashes(remoteRefs)
+	if err != nil {
+		return err
+	}
+
+	shallows, err := r.Storer.Shallow()
+	if err != nil {
+		return err
+	}
+
+	hashes, err := revlist.Objects(r.Storer, wants, append(haves, shallows...))
+	if err != nil {
+		return err
+	}
+
+	storerConfig, err := r.Storer.Config()
+	if err != nil {
+		return err
+	}
+
+	pack, w := io.Pipe()
+	req.Packfile = pack
+
+	// buffered, so the encoder never blocks when ReceivePack fails
+	done := make(chan error, 1)
+	go func() {
+		e := packfile.NewEncoder(w, r.Storer, !ar.Capabilities.Supports(capability.OFSDelta))
+		_, err := e.Encode(hashes, storerConfig.Pack.Window)
+		done <- w.CloseWithError(err)
+	}()
+
+	rs, err := s.ReceivePack(context.Background(), req)
+	if err != nil {
+		_ = pack.Close()
+		return authError(cfg, err)
+	}
+	if err := <-done; err != nil {
+		return err
+	}
+
+	return rs.Error()
+}
+
+// checkFastForward rejects updates of existing remote tags, and of branches to commits not descending from them.
+func checkFastForward(r *git.Repository, cmd *packp.Command) error {
+	if cmd.Name.IsTag() {
+		return fmt.Errorf("tag already exists: %s", cmd.Name.Short())
+	}
+
+	old, err := r.CommitObject(cmd.Old)
+	if err != nil {
+		return fmt.Errorf("non-fast-forward update: %s", cmd.Name.String())
+	}
+
+	c, err := r.CommitObject(cmd.New)
+	if err != nil {
+		return err
+	}
+
+	ok, err := old.IsAncestor(c)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("non-fast-forward update: %s", cmd.Name.String())
+	}
+	return nil
+}
+
+// referenceHashes returns the hashes which the references point to, skipping symbolic references.
+func referenceHashes(refs storer.ReferenceStorer) ([]plumbing.Hash, error) {
+	iter, err := refs.IterReferences()
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes []plumbing.Hash
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			hashes = append(hashes, ref.Hash())
+		}
+		return nil
+	})
+	return hashes, err
+}
+
+// fetchTags fetches all tags of the configured remote, which replace local tags of the same names.
+func fetchTags(r *git.Repository, cfg *Config) error {
+	remote, err := openRemote(r, cfg)
+	if err != nil {
+		return err
+	}
+
+	auth, err := newAuth(cfg)
+	if err != nil {
+		return err
+	}
+
+	err = remote.Fetch(&git.FetchOptions{
+		RemoteName: cfg.Remote,
+		Auth:       auth,
+		RefSpecs:   []config.RefSpec{"+refs/tags/*:refs/tags/*"},
+		Tags:       git.NoTags,
+	})
+	if err == git.NoErrAlreadyUpToDate {
+		return nil
+	}
+	return authError(cfg, err)
+}
+
+// deepen fetches the history of head up to depth commits from the configured remote, like git fetch --depth.
+// go-git doesn't send the shallow commits of repository when fetching, so the upload-pack request is made here.
+func deepen(r *git.Repository, cfg *Config, head plumbing.Hash, depth int) (err error) {
+	remote, err := openRemote(r, cfg)
+	if err != nil {
+		return err
+	}
+
+	auth, err := newAuth(cfg)
+	if err != nil {
+		return err
+	}
+
+	ep, err := transport.NewEndpoint(remote.Config().URLs[0])
+	if err != nil {
+		return err
+	}
+
+	c, err := client.NewClient(ep)
+	if err != nil {
+		return err
+	}
+
+	s, err := c.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := s.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	ar, err := s.AdvertisedReferences()
+	if err != nil {
+		return authError(cfg, err)
+	}
+	if !ar.Capabilities.Supports(capability.Shallow) {
+		return fmt.Errorf("remote %s doesn't support shallow fetch", cfg.Remote)
+	}
+
+	shallows, err := r.Storer.Shallow()
+	if err != nil {
+		return err
+	}
+
+	req := packp.NewUploadPackRequestFromCapabilities(ar.Capabilities)
+	if err := req.Capabilities.Set(capability.Shallow); err != nil {
+		return err
+	}
+	req.Depth = packp.DepthCommits(depth)
+	req.Wants = []plumbing.Hash{head}
+	req.Shallows = shallows
+
+	resp, err := s.UploadPack(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+
+	var pack io.Reader = resp
+	switch {
+	case req.Capabilities.Supports(capability.Sideband64k):
+		pack = sideband.NewDemuxer(sideband.Sideband64k, resp)
+	case req.Capabilities.Supports(capability.Sideband):
+		pack = sideband.NewDemuxer(sideband.Sideband, resp)
+	}
+
+	if err := packfile.UpdateObjectStorage(r.Storer, pack); err != nil {
+		return err
+	}
+
+	return r.Storer.SetShallow(updateShallows(shallows, resp.ShallowUpdate))
+}
+
+// updateShallows applies the shallow update of upload-pack response to the shallow commits of repository.
+func updateShallows(shallows []plumbing.Hash, update packp.ShallowUpdate) []plumbing.Hash {
+	set := map[plumbing.Hash]bool{}
+	for _, h := range shallows {
+		set[h] = true
+	}
+	for _, h := range update.Shallows {
+		set[h] = true
+	}
+	for _, h := range update.Unshallows {
+		delete(set, h)
+	}
+
+	var updated []plumbing.Hash
+	for _, h := range append(shallows, update.Shallows...) {
+		if set[h] {
+			updated = append(updated, h)
+			delete(set, h)
+		}
+	}
+	return updated
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/stretchr/testify/assert"
+	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func generateSSHKey(t *testing.T) ([]byte, gossh.PublicKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	pub, err := gossh.NewPublicKey(&key.PublicKey)
+	assert.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), pub
+}
+
+func TestConfig_Auth(t *testing.T) {
+	assert.Equal(t, AuthNone, testConfig(t, nil).Auth)
+	assert.Equal(t, AuthToken, testConfig(t, func(c *Config) { c.RepoToken = "secret" }).Auth)
+	assert.Equal(t, AuthSSHKey, testConfig(t, func(c *Config) { c.SSHPrivateKey = "key" }).Auth)
+	assert.Equal(t, AuthSSHAgent, testConfig(t, func(c *Config) { c.Auth = AuthSSHAgent }).Auth)
+
+	invalid := map[string]func(*Config){
+		"auth":            func(c *Config) { c.Auth = "password" },
+		"repo_token":      func(c *Config) { c.Auth = AuthToken },
+		"ssh_private_key": func(c *Config) { c.Auth = AuthSSHKey },
+		"ssh_user":        func(c *Config) { c.Auth = AuthSSHAgent; c.SSHUser = "" },
+		"remote":          func(c *Config) { c.Remote = "" },
+	}
+	for key, fn := range invalid {
+		cfg := DefaultConfig()
+		fn(cfg)
+		err := cfg.Validate()
+		if assert.IsType(t, &ConfigError{}, err, key) {
+			assert.Equal(t, key, err.(*ConfigError).Key)
+		}
+	}
+}
+
+func TestNewAuth(t *testing.T) {
+	auth, err := newAuth(testConfig(t, nil))
+	assert.NoError(t, err)
+	assert.Nil(t, auth)
+
+	auth, err = newAuth(testConfig(t, func(c *Config) { c.RepoToken = "secret" }))
+	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{Username: "USER_NAME", Password: "secret"}, auth)
+
+	_, err = newAuth(testConfig(t, func(c *Config) { c.SSHPrivateKey = "not a key" }))
+	assert.Error(t, err)
+}
+
+func TestNewAuth_SSHKey(t *testing.T) {
+	key, pub := generateSSHKey(t)
+	_, other := generateSSHKey(t)
+
+	cfg := testConfig(t, func(c *Config) {
+		c.SSHPrivateKey = string(key)
+		c.SSHKnownHosts = knownhosts.Line([]string{"git.example.com"}, pub)
+	})
+
+	auth, err := newAuth(cfg)
+	if !assert.NoError(t, err) {
+		return
+	}
+	keys := auth.(*ssh.PublicKeys)
+	assert.Equal(t, "git", keys.User)
+
+	addr := &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 22}
+	assert.NoError(t, keys.HostKeyCallback("git.example.com:22", addr, pub))
+	assert.Error(t, keys.HostKeyCallback("git.example.com:22", addr, other), "changed host key")
+	assert.Error(t, keys.HostKeyCallback("unknown.example.com:22", addr, pub), "unknown host")
+}
+
+func TestPush(t *testing.T) {
+	remoteDir := t.TempDir()
+	_, err := git.PlainInit(remoteDir, true)
+	assert.NoError(t, err)
+
+	r := newTestRepo(t, "master")
+	r.tag("v1.0.0", r.commit("feat: first"))
+	refSpecs := []config.RefSpec{"refs/tags/v1.0.0:refs/tags/v1.0.0"}
+
+	// no remote named origin
+	err = push(r.Repository, testConfig(t, nil), refSpecs)
+	assert.Error(t, err)
+
+	err = push(r.Repository, testConfig(t, func(c *Config) { c.RemoteURL = remoteDir }), refSpecs)
+	assert.NoError(t, err)
+
+	remote, err := git.PlainOpen(remoteDir)
+	assert.NoError(t, err)
+	_, err = remote.Reference(plumbing.NewTagReferenceName("v1.0.0"), false)
+	assert.NoError(t, err)
+
+	// the named remote of repository
+	_, err = r.CreateRemote(&config.RemoteConfig{Name: "upstream", URLs: []string{remoteDir}})
+	assert.NoError(t, err)
+	r.tag("v1.0.1", r.commit("fix: second"))
+
+	err = push(r.Repository, testConfig(t, func(c *Config) { c.Remote = "upstream" }), []config.RefSpec{"refs/tags/v1.0.1:refs/tags/v1.0.1"})
+	assert.NoError(t, err)
+	_, err = remote.Reference(plumbing.NewTagReferenceName("v1.0.1"), false)
+	assert.NoError(t, err)
+}
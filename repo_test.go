@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+)
+
+// testRepo is a throwaway repository on disk used to run workflows against.
+type testRepo struct {
+	t *testing.T
+	*git.Repository
+}
+
+func newTestRepo(t *testing.T, branch string) *testRepo {
+	r, err := git.PlainInit(t.TempDir(), false)
+	assert.NoError(t, err)
+
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(branch))
+	assert.NoError(t, r.Storer.SetReference(head))
+
+	return &testRepo{t: t, Repository: r}
+}
+
+func (r *testRepo) commit(message string) plumbing.Hash {
+	return r.commitOn(message)
+}
+
+// commitOn commits on parents, e.g. two of them for a merge, or on HEAD if none, and moves HEAD to the commit.
+func (r *testRepo) commitOn(message string, parents ...plumbing.Hash) plumbing.Hash {
+	w, err := r.Worktree()
+	assert.NoError(r.t, err)
+
+	h, err := w.Commit(message, &git.CommitOptions{
+		Author:  &object.Signature{Name: "tester", Email: "tester@example.com", When: time.Now()},
+		Parents: parents,
+	})
+	assert.NoError(r.t, err)
+	return h
+}
+
+// commitFile writes file under worktree and commits it.
+func (r *testRepo) commitFile(file, message string) plumbing.Hash {
+	w, err := r.Worktree()
+	assert.NoError(r.t, err)
+
+	path := filepath.Join(w.Filesystem.Root(), file)
+	assert.NoError(r.t, os.MkdirAll(filepath.Dir(path), 0755))
+	assert.NoError(r.t, os.WriteFile(path, []byte(message), 0644))
+
+	_, err = w.Add(file)
+	assert.NoError(r.t, err)
+
+	return r.commit(message)
+}
+
+func (r *testRepo) tag(name string, h plumbing.Hash) {
+	_, err := r.CreateTag(name, h, nil)
+	assert.NoError(r.t, err)
+}
+
+func (r *testRepo) annotatedTag(name string, h plumbing.Hash) {
+	_, err := r.CreateTag(name, h, &git.CreateTagOptions{
+		Tagger:  &object.Signature{Name: "tester", Email: "tester@example.com", When: time.Now()},
+		Message: name,
+	})
+	assert.NoError(r.t, err)
+}
+
+// testConfig returns the validated default configuration, modified by fn if given.
+func testConfig(t *testing.T, fn func(*Config)) *Config {
+	cfg := DefaultConfig()
+	if fn != nil {
+		fn(cfg)
+	}
+	assert.NoError(t, cfg.Validate())
+	return cfg
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+)
+
+// loadSignKey reads the armored OpenPGP private key to sign tags, decrypting it with passphrase if it is encrypted.
+// The primary key and subkeys are all decrypted. Tags are signed by the first signing subkey which is not expired,
+// or by the primary key without signing subkeys, and the signing key must not be expired at now.
+func loadSignKey(armored, passphrase string, now time.Time) (*openpgp.Entity, error) {
+	if strings.TrimSpace(armored) == "" {
+		return nil, fmt.Errorf("gpg private key is missing")
+	}
+
+	entities, err := openpgp.ReadArmoredKeyRing(strings.NewReader(armored))
+	if err != nil {
+		return nil, fmt.Errorf("invalid gpg private key: %s", err)
+	}
+
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("invalid gpg private key: no key in the armored block")
+	}
+
+	entity := entities[0]
+	if entity.PrivateKey == nil {
+		return nil, fmt.Errorf("gpg key %s has no private key", entity.PrimaryKey.KeyIdString())
+	}
+
+	if err := decryptKey(entity.PrivateKey, passphrase); err != nil {
+		return nil, err
+	}
+	for _, subkey := range entity.Subkeys {
+		if subkey.PrivateKey == nil {
+			continue
+		}
+		if err := decryptKey(subkey.PrivateKey, passphrase); err != nil {
+			return nil, err
+		}
+	}
+
+	// lifetime of key is counted from the creation of key, see RFC 4880 5.2.3.6
+	for _, identity := range entity.Identities {
+		sig := identity.SelfSignature
+		if sig.KeyLifetimeSecs == nil || *sig.KeyLifetimeSecs == 0 {
+			continue
+		}
+
+		expiry := entity.PrimaryKey.CreationTime.Add(time.Duration(*sig.KeyLifetimeSecs) * time.Second)
+		if now.After(expiry) {
+			return nil, fmt.Errorf("gpg key %s of %s expired at %s", entity.PrimaryKey.KeyIdString(), identity.Name, expiry.Format(time.RFC3339))
+		}
+	}
+
+	// go-git signs tags by openpgp detached signatures, which are always made by the private key of entity,
+	// so the first signing subkey which is not expired replaces it, as openpgp chooses for messages.
+	// Expired signing subkeys are not silently replaced by the primary key.
+	var expired *openpgp.Subkey
+	for i, subkey := range entity.Subkeys {
+		if !subkey.Sig.FlagsValid || !subkey.Sig.FlagSign || !subkey.PublicKey.PubKeyAlgo.CanSign() || subkey.PrivateKey == nil {
+			continue
+		}
+		if !subkey.Sig.KeyExpired(now) {
+			signer := *entity
+			signer.PrivateKey = subkey.PrivateKey
+			return &signer, nil
+		}
+		if expired == nil {
+			expired = &entity.Subkeys[i]
+		}
+	}
+	if expired != nil {
+		expiry := expired.PublicKey.CreationTime.Add(time.Duration(*expired.Sig.KeyLifetimeSecs) * time.Second)
+		return nil, fmt.Errorf("gpg signing subkey %s of key %s expired at %s", expired.PublicKey.KeyIdString(), entity.PrimaryKey.KeyIdString(), expiry.Format(time.RFC3339))
+	}
+
+	return entity, nil
+}
+
+// decryptKey decrypts the private key or subkey with passphrase if it is encrypted.
+func decryptKey(key *packet.PrivateKey, passphrase string) error {
+	if !key.Encrypted {
+		return nil
+	}
+
+	if passphrase == "" {
+		return fmt.Errorf("gpg private key %s is encrypted, but passphrase is missing", key.KeyIdString())
+	}
+
+	if err := key.Decrypt([]byte(passphrase)); err != nil {
+		return fmt.Errorf("failed to decrypt gpg private key %s, wrong passphrase: %s", key.KeyIdString(), err)
+	}
+	return nil
+}
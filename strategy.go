@@ -0,0 +1,388 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// Strategy computes the next version by a versioning scheme, such as branching model of team.
+type Strategy interface {
+	Name() string
+	Next(ctx *StrategyContext) (*NextVersion, error)
+}
+
+// StrategyContext is the input of Strategy.
+type StrategyContext struct {
+	Repo   *git.Repository
+	Head   *plumbing.Reference
+	Tags   []*VersionTag // version tags of Template
+	Config *Config
+	Now    time.Time // in the configured timezone
+
+	// Template and Paths are of the component, or the configured template and nil for whole repository.
+	Template *TagTemplate
+	Paths    []string
+}
+
+// NextVersion is the output of Strategy.
+type NextVersion struct {
+	Version  *VersionTag
+	Previous *VersionTag // nil for the first version
+	Part     Bump
+	Reason   string
+	Strategy string
+}
+
+var strategies = map[string]Strategy{
+	"release-branch": releaseBranchStrategy{},
+	"build-number":   buildNumberStrategy{},
+	"trunk":          trunkStrategy{},
+	"git-flow":       gitFlowStrategy{},
+	"calver":         calverStrategy{},
+}
+
+func strategyNames() []string {
+	var names []string
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// selectStrategy returns the configured strategy, or the first one whose branch pattern matches HEAD.
+func selectStrategy(head *plumbing.Reference, cfg *Config) (Strategy, error) {
+	if cfg.Strategy != "" {
+		return strategies[cfg.Strategy], nil
+	}
+
+	branchName := head.Name().String()
+
+	var patterns []string
+	for _, b := range cfg.branchStrategies() {
+		if b.regex.MatchString(branchName) {
+			return strategies[b.Strategy], nil
+		}
+		patterns = append(patterns, b.regex.String())
+	}
+
+	return nil, &BranchMismatchError{Branch: branchName, Patterns: patterns}
+}
+
+// computeNext computes the next version by the strategy selected for HEAD.
+// component can be nil to version whole repository.
+func computeNext(r *git.Repository, cfg *Config, component *Component) (*NextVersion, error) {
+	ctx, err := newStrategyContext(r, cfg, component)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := selectStrategy(ctx.Head, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := strategy.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Prerelease != "" {
+		next, err = applyChannel(ctx, next, cfg.Prerelease)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	next.Strategy = strategy.Name()
+	return next, nil
+}
+
+// newStrategyContext reads HEAD and version tags of repository, or of component if not nil.
+func newStrategyContext(r *git.Repository, cfg *Config, component *Component) (*StrategyContext, error) {
+	h, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := r.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := &StrategyContext{Repo: r, Head: h, Config: cfg, Now: cfg.now(), Template: cfg.template(component)}
+	if component != nil {
+		ctx.Paths = component.Paths
+	}
+
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		current, err := parseTag(ref, ctx.Template)
+		if err != nil {
+			return nil
+		}
+
+		ctx.Tags = append(ctx.Tags, current)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ctx, nil
+}
+
+// latest returns the version tag of the highest precedence among those accepted by filter, or nil if none.
+func (ctx *StrategyContext) latest(filter func(*VersionTag) bool) *VersionTag {
+	var latest *VersionTag
+	for _, current := range ctx.Tags {
+		if !filter(current) {
+			continue
+		}
+
+		if latest == nil || Compare(current, latest) > 0 {
+			latest = current
+		}
+	}
+	return latest
+}
+
+// latestInHistory returns the latest version tag accepted by filter whose commit is in the history of HEAD, or nil if none.
+// Tags of other branches, e.g. unmerged hotfixes or tags pushed by another run on later commits, are no previous versions
+// of HEAD. In shallow repository, tags beyond the fetched history count, until ensureHistory deepens it.
+func (ctx *StrategyContext) latestInHistory(filter func(*VersionTag) bool) (*VersionTag, error) {
+	history, cut, err := ancestors(ctx.Repo, ctx.Head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *VersionTag
+	for _, current := range ctx.Tags {
+		if !filter(current) || (latest != nil && Compare(current, latest) <= 0) {
+			continue
+		}
+
+		h, err := tagCommit(ctx.Repo, current.ref)
+		if err != nil {
+			return nil, err
+		}
+		if history[h] || cut {
+			latest = current
+		}
+	}
+	return latest, nil
+}
+
+// bumpByCommits bumps base by Conventional Commits since previous.
+// Every run makes a tag, so at least the patch number is increased.
+func (ctx *StrategyContext) bumpByCommits(base, previous *VersionTag) (*NextVersion, error) {
+	commits, err := commitsSince(ctx.Repo, previous, ctx.Paths)
+	if err != nil {
+		return nil, err
+	}
+
+	bump, reason := analyzeCommits(commits)
+	if bump < BumpPatch {
+		bump = BumpPatch
+		reason = "no commits requiring minor or major bump"
+	}
+
+	return &NextVersion{
+		Version:  bumped(base, bump),
+		Previous: previous,
+		Part:     bump,
+		Reason:   reason,
+	}, nil
+}
+
+func bumped(v *VersionTag, b Bump) *VersionTag {
+	next := *v
+	next.ref = nil
+	next.Increment(b)
+	return &next
+}
+
+func isRelease(v *VersionTag) bool {
+	return v.Pre == ""
+}
+
+// releaseBranchStrategy bumps the latest version of the version line of release branch,
+// e.g. X.Y on release/X.Y, X on release/X.x and support/X, and X.Y.Z on hotfix/X.Y.Z.
+// The bump is limited to the line: minor on lines of major, and patch on lines of major and minor or three numbers.
+type releaseBranchStrategy struct{}
+
+func (releaseBranchStrategy) Name() string {
+	return "release-branch"
+}
+
+func (releaseBranchStrategy) Next(ctx *StrategyContext) (*NextVersion, error) {
+	branchName := ctx.Head.Name().String()
+
+	var line *versionLine
+	var patterns []string
+	for _, b := range ctx.Config.releaseBranches {
+		if line = b.match(branchName); line != nil {
+			break
+		}
+		patterns = append(patterns, b.pattern)
+	}
+
+	if !ctx.Head.Name().IsBranch() || line == nil {
+		return nil, &BranchMismatchError{Branch: branchName, Patterns: patterns}
+	}
+
+	// only tags of this line
+	previous, err := ctx.latestInHistory(line.contains)
+	if err != nil {
+		return nil, err
+	}
+
+	base := previous
+	if base == nil {
+		base = line.base(ctx.Template)
+	}
+
+	next, err := ctx.bumpByCommits(base, previous)
+	if err != nil {
+		return nil, err
+	}
+
+	// the bump required by commits is lowered, e.g. a feature on release/1.2 is tagged as a patch
+	if max := line.maxBump(); next.Part > max {
+		Warning("Commits require %s bump, but it is limited to %s on %s to stay in its version line: %s", next.Part, max, ctx.Head.Name().Short(), next.Reason)
+		next.Version = bumped(base, max)
+		next.Reason = fmt.Sprintf("%s, limited to %s on %s", next.Reason, max, ctx.Head.Name().Short())
+		next.Part = max
+	}
+	return next, nil
+}
+
+// buildNumberStrategy increases build number, the numeric pre-release identifier, of the latest version.
+// e.g. 0.1.0-0 -> 0.1.0-1, and 0.1.0 -> 0.1.1-0 once the base version has been released.
+type buildNumberStrategy struct{}
+
+func (buildNumberStrategy) Name() string {
+	return "build-number"
+}
+
+func (buildNumberStrategy) Next(ctx *StrategyContext) (*NextVersion, error) {
+	if !ctx.Head.Name().IsBranch() {
+		return nil, &BranchMismatchError{Branch: ctx.Head.Name().String(), Patterns: []string{"refs/heads/*"}}
+	}
+
+	latest := ctx.latest(func(*VersionTag) bool { return true })
+	if latest == nil {
+		return &NextVersion{
+			Version: &VersionTag{
+				Tag:    ctx.Template.Prefix,
+				Major:  0,
+				Minor:  1,
+				Patch:  0,
+				Pre:    "0",
+				Suffix: ctx.Template.Suffix,
+			},
+			Part:   BumpPrerelease,
+			Reason: "first build",
+		}, nil
+	}
+
+	version := bumped(latest, BumpPrerelease)
+	reason := "next build of " + latest.String()
+
+	// base version is already released, so builds go on to the next patch
+	if isRelease(latest) {
+		version = bumped(latest, BumpPatch)
+		version.Increment(BumpPrerelease)
+		reason = "first build after " + latest.String()
+	}
+
+	return &NextVersion{
+		Version:  version,
+		Previous: latest,
+		Part:     BumpPrerelease,
+		Reason:   reason,
+	}, nil
+}
+
+// trunkStrategy bumps the latest release by Conventional Commits, for trunk-based development
+// where every release is tagged on the main branch.
+type trunkStrategy struct{}
+
+func (trunkStrategy) Name() string {
+	return "trunk"
+}
+
+func (trunkStrategy) Next(ctx *StrategyContext) (*NextVersion, error) {
+	previous, err := ctx.latestInHistory(isRelease)
+	if err != nil {
+		return nil, err
+	}
+
+	base := previous
+	if base == nil {
+		base = &VersionTag{Tag: ctx.Template.Prefix, Suffix: ctx.Template.Suffix}
+	}
+
+	return ctx.bumpByCommits(base, previous)
+}
+
+// gitFlowStrategy releases the version prepared on develop branch when it is merged into the production branch.
+// The latest pre-release, e.g. 1.3.0-4 built by build-number strategy, is promoted to 1.3.0.
+// Without pre-release ahead of the latest release, it works as trunkStrategy.
+type gitFlowStrategy struct{}
+
+func (gitFlowStrategy) Name() string {
+	return "git-flow"
+}
+
+func (gitFlowStrategy) Next(ctx *StrategyContext) (*NextVersion, error) {
+	latest, err := ctx.latestInHistory(func(*VersionTag) bool { return true })
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil || isRelease(latest) {
+		return trunkStrategy{}.Next(ctx)
+	}
+
+	version := &VersionTag{
+		Tag:    latest.Tag,
+		Major:  latest.Major,
+		Minor:  latest.Minor,
+		Patch:  latest.Patch,
+		Suffix: latest.Suffix,
+	}
+	previous, err := ctx.latestInHistory(isRelease)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NextVersion{
+		Version:  version,
+		Previous: previous,
+		Part:     bumpBetween(previous, version),
+		Reason:   "promote pre-release " + latest.String(),
+	}, nil
+}
+
+// bumpBetween returns the highest part which differs between versions. prev can be nil for the first version.
+func bumpBetween(prev, next *VersionTag) Bump {
+	if prev == nil {
+		prev = &VersionTag{}
+	}
+
+	switch {
+	case prev.Major != next.Major:
+		return BumpMajor
+	case prev.Minor != next.Minor:
+		return BumpMinor
+	case prev.Patch != next.Patch:
+		return BumpPatch
+	case prev.Pre != next.Pre:
+		return BumpPrerelease
+	default:
+		return BumpNone
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nextVersion(t *testing.T, r *testRepo, cfg *Config) *NextVersion {
+	next, err := computeNext(r.Repository, cfg, nil)
+	assert.NoError(t, err)
+	return next
+}
+
+func TestReleaseBranchStrategy(t *testing.T) {
+	r := newTestRepo(t, "release/1.2")
+	r.annotatedTag("v1.2.3", r.commit("initial"))
+	r.commit("fix: first")
+	r.commit("feat: second")
+
+	// the lowered bump is warned
+	var logs bytes.Buffer
+	defer func(l *Logger) { logger = l }(logger)
+	logger = NewLogger(&logs, false)
+
+	next := nextVersion(t, r, testConfig(t, nil))
+	assert.Equal(t, "release-branch", next.Strategy)
+	assert.Equal(t, BumpPatch, next.Part)
+	assert.Equal(t, "v1.2.4", next.Version.String())
+	assert.Contains(t, next.Reason, "limited to patch on release/1.2")
+	assert.Contains(t, logs.String(), "::warning::Commits require minor bump, but it is limited to patch on release/1.2")
+	assert.Equal(t, "v1.2.3", previousTag(next.Previous))
+
+	r = newTestRepo(t, "release/1.2")
+	r.tag("v1.2.3", r.commit("initial"))
+	r.commit("chore: nothing")
+
+	next = nextVersion(t, r, testConfig(t, nil))
+	assert.Equal(t, BumpPatch, next.Part)
+	assert.Equal(t, "v1.2.4", next.Version.String())
+
+	r = newTestRepo(t, "release/1.2")
+	r.commit("initial")
+
+	next = nextVersion(t, r, testConfig(t, nil))
+	assert.Equal(t, "v1.2.1", next.Version.String())
+	assert.Nil(t, next.Previous)
+}
+
+func TestReleaseBranchStrategy_Rerun(t *testing.T) {
+	r := newTestRepo(t, "release/1.2")
+	r.tag("v1.2.3", r.commit("initial"))
+	h := r.commit("feat: first")
+
+	next := nextVersion(t, r, testConfig(t, nil))
+	assert.Equal(t, "v1.2.4", next.Version.String())
+
+	// a minor bump would leave the line, and the next run would compute the same version again
+	r.tag(next.Version.String(), h)
+	r.commit("feat: second")
+
+	next = nextVersion(t, r, testConfig(t, nil))
+	assert.Equal(t, "v1.2.5", next.Version.String())
+	assert.Equal(t, "v1.2.4", previousTag(next.Previous))
+}
+
+func TestReleaseBranchStrategy_Latest(t *testing.T) {
+	r := newTestRepo(t, "release/1.2")
+	r.tag("v1.2.3-rc.10", r.commit("first"))
+	r.tag("v1.2.3-rc.9", r.commit("second"))
+	r.tag("v1.3.0", r.commit("other release"))
+	r.commit("fix: third")
+
+	// the pre-release is released rather than skipped
+	next := nextVersion(t, r, testConfig(t, nil))
+	assert.Equal(t, "v1.2.3", next.Version.String())
+	assert.Equal(t, "v1.2.3-rc.10", previousTag(next.Previous))
+}
+
+func TestBuildNumberStrategy(t *testing.T) {
+	r := newTestRepo(t, "develop")
+	h := r.commit("initial")
+
+	next := nextVersion(t, r, testConfig(t, nil))
+	assert.Equal(t, "build-number", next.Strategy)
+	assert.Equal(t, BumpPrerelease, next.Part)
+	assert.Equal(t, "v0.1.0-0", next.Version.String())
+
+	cfg := testConfig(t, func(cfg *Config) { cfg.WithoutV = true })
+
+	r.tag("0.1.0-0", h)
+	r.tag("0.1.0-9", r.commit("second"))
+	r.tag("0.1.0-10", r.commit("third"))
+	r.commit("fourth")
+
+	next = nextVersion(t, r, cfg)
+	assert.Equal(t, "0.1.0-11", next.Version.String())
+	assert.Equal(t, "0.1.0-10", previousTag(next.Previous))
+
+	r.tag("0.1.0", r.commit("release"))
+
+	next = nextVersion(t, r, cfg)
+	assert.Equal(t, "0.1.1-0", next.Version.String())
+}
+
+func TestTrunkStrategy(t *testing.T) {
+	cfg := testConfig(t, func(cfg *Config) { cfg.Strategy = "trunk" })
+
+	r := newTestRepo(t, "main")
+	r.commit("initial")
+
+	next := nextVersion(t, r, cfg)
+	assert.Equal(t, "trunk", next.Strategy)
+	assert.Equal(t, "v0.0.1", next.Version.String())
+
+	r.tag("v1.4.2", r.commit("fix: release"))
+	r.tag("v1.5.0-rc.1", r.commit("feat: prerelease"))
+	r.commit("feat!: breaking")
+
+	next = nextVersion(t, r, cfg)
+	assert.Equal(t, BumpMajor, next.Part)
+	assert.Equal(t, "v2.0.0", next.Version.String())
+	assert.Equal(t, "v1.4.2", previousTag(next.Previous))
+}
+
+func TestGitFlowStrategy(t *testing.T) {
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Branches = []BranchStrategy{{Pattern: "^refs/heads/(main|master)$", Strategy: "git-flow"}}
+	})
+
+	r := newTestRepo(t, "master")
+	r.tag("v1.4.2", r.commit("initial"))
+	r.tag("v1.5.0-3", r.commit("feat: build on develop"))
+	r.commit("Merge branch 'develop'")
+
+	next := nextVersion(t, r, cfg)
+	assert.Equal(t, "git-flow", next.Strategy)
+	assert.Equal(t, "v1.5.0", next.Version.String())
+	assert.Equal(t, BumpMinor, next.Part)
+	assert.Equal(t, "v1.4.2", previousTag(next.Previous))
+
+	r.tag("v1.5.0", r.commit("release"))
+	r.commit("fix: hotfix")
+
+	next = nextVersion(t, r, cfg)
+	assert.Equal(t, "v1.5.1", next.Version.String())
+}
+
+func TestSelectStrategy(t *testing.T) {
+	r := newTestRepo(t, "feature/foo")
+	r.commit("initial")
+
+	_, err := computeNext(r.Repository, testConfig(t, nil), nil)
+	if assert.IsType(t, &BranchMismatchError{}, err) {
+		assert.Equal(t, "refs/heads/feature/foo", err.(*BranchMismatchError).Branch)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Strategy = "unknown"
+	err = cfg.Validate()
+	if assert.IsType(t, &ConfigError{}, err) {
+		assert.Equal(t, "strategy", err.(*ConfigError).Key)
+	}
+}
+
+func TestReleaseBranchStrategy_Lines(t *testing.T) {
+	r := newTestRepo(t, "release/1.x")
+	r.tag("v1.2.3", r.commit("initial"))
+	r.tag("v2.0.0", r.commit("feat!: next major"))
+	r.commit("feat!: breaking")
+
+	// the major number is never bumped on release branches
+	next := nextVersion(t, r, testConfig(t, nil))
+	assert.Equal(t, "release-branch", next.Strategy)
+	assert.Equal(t, BumpMinor, next.Part)
+	assert.Equal(t, "v1.3.0", next.Version.String())
+	assert.Equal(t, "v1.2.3", previousTag(next.Previous))
+	assert.Contains(t, next.Reason, "limited to minor on release/1.x")
+
+	r = newTestRepo(t, "hotfix/1.2.3")
+	r.tag("v1.2.3", r.commit("initial"))
+	r.tag("v1.2.4", r.commit("fix: first"))
+	r.tag("v1.3.0", r.commit("feat: other"))
+	r.commit("feat: second")
+
+	next = nextVersion(t, r, testConfig(t, nil))
+	assert.Equal(t, BumpPatch, next.Part)
+	assert.Equal(t, "v1.2.5", next.Version.String())
+	assert.Equal(t, "v1.2.4", previousTag(next.Previous))
+
+	r = newTestRepo(t, "support/2")
+	r.commit("initial")
+
+	next = nextVersion(t, r, testConfig(t, nil))
+	assert.Equal(t, "v2.0.1", next.Version.String())
+	assert.Nil(t, next.Previous)
+
+	// not anchored patterns matched old-release/1.2-wip
+	r = newTestRepo(t, "old-release/1.2-wip")
+	r.commit("initial")
+
+	_, err := computeNext(r.Repository, testConfig(t, nil), nil)
+	assert.IsType(t, &BranchMismatchError{}, err)
+
+	cfg := testConfig(t, func(cfg *Config) { cfg.ReleaseBranches = []string{"maint/{major}.{minor}"} })
+	r = newTestRepo(t, "maint/3.1")
+	r.commit("initial")
+
+	next = nextVersion(t, r, cfg)
+	assert.Equal(t, "v3.1.1", next.Version.String())
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// renderSummary renders the report of run in Markdown: each new tag with the previous one,
+// the reason of bump, the strategy, commits since the previous tag and the link to compare them.
+// Version tags HEAD already has are not reported.
+func renderSummary(cfg *Config, plans []*plannedTag) string {
+	summary := "## Tag version\n\n"
+	if cfg.DryRun {
+		summary += "Dry run, tags are neither created nor pushed.\n\n"
+	}
+
+	var sections []string
+	for _, p := range plans {
+		if p.Existing == nil {
+			sections = append(sections, renderSummarySection(cfg, p))
+		}
+	}
+	if len(sections) == 0 {
+		return summary + "Nothing to tag, no components changed or HEAD is already tagged.\n"
+	}
+	return summary + strings.Join(sections, "\n")
+}
+
+func renderSummarySection(cfg *Config, p *plannedTag) string {
+	next := p.Next
+	version := next.Version.String()
+	previous := previousTag(next.Previous)
+
+	title := version
+	if p.Component != nil {
+		title = p.Component.Name + ": " + version
+	}
+
+	section := "### " + title + "\n\n"
+	section += "| | |\n|---|---|\n"
+	section += fmt.Sprintf("| Previous tag | %s |\n", codeOrNone(previous))
+	section += fmt.Sprintf("| New tag | `%s` |\n", version)
+	section += fmt.Sprintf("| Bump | %s: %s |\n", next.Part, escapeTableCell(next.Reason))
+	section += fmt.Sprintf("| Strategy | %s |\n", next.Strategy)
+	if len(p.Floating) > 0 {
+		section += fmt.Sprintf("| Floating tags | `%s` |\n", strings.Join(p.Floating, "`, `"))
+	}
+	if link := compareLink(cfg, previous, version); link != "" {
+		section += fmt.Sprintf("| Compare | %s |\n", link)
+	}
+
+	section += "\n"
+	if len(p.Commits) == 0 {
+		return section + "Nothing new, just for tagging.\n"
+	}
+
+	section += "Commits:\n\n"
+	for _, c := range p.Commits {
+		subject := strings.SplitN(strings.TrimSpace(c.Message), "\n", 2)[0]
+		section += fmt.Sprintf("- %s (`%s`)\n", subject, c.Hash.String()[:7])
+	}
+	return section
+}
+
+// compareLink links the comparison of tags on GitHub, or the tree of tag for the first version.
+// It is empty without github_repository.
+func compareLink(cfg *Config, previous, version string) string {
+	if cfg.GithubRepository == "" {
+		return ""
+	}
+
+	base := strings.TrimSuffix(cfg.GithubServerURL, "/") + "/" + cfg.GithubRepository
+	if previous == "" {
+		return fmt.Sprintf("[%s](%s/tree/%s)", version, base, version)
+	}
+	return fmt.Sprintf("[%s...%s](%s/compare/%s...%s)", previous, version, base, previous, version)
+}
+
+func codeOrNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return "`" + s + "`"
+}
+
+func escapeTableCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// writeSummary appends the report to the job summary of GITHUB_STEP_SUMMARY, which is skipped when it is not set.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions#adding-a-job-summary
+func writeSummary(cfg *Config, plans []*plannedTag) error {
+	path := os.Getenv("GITHUB_STEP_SUMMARY")
+	if path == "" {
+		Debug("GITHUB_STEP_SUMMARY is not set, job summary is not written")
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.WriteString(renderSummary(cfg, plans)); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
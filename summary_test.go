@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderSummary(t *testing.T) {
+	r := newTestRepo(t, "main")
+	r.tag("v1.0.0", r.commit("chore: first"))
+	r.commit("feat: second")
+	fix := r.commit("fix: third | fourth\n\nbody")
+
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.GithubRepository = "owner/repo"
+	})
+
+	plans, err := planTags(r.Repository, cfg)
+	assert.NoError(t, err)
+
+	summary := renderSummary(cfg, plans)
+	assert.Contains(t, summary, "### v1.1.0\n")
+	assert.Contains(t, summary, "| Previous tag | `v1.0.0` |\n")
+	assert.Contains(t, summary, "| New tag | `v1.1.0` |\n")
+	assert.Contains(t, summary, "| Strategy | trunk |\n")
+	assert.Contains(t, summary, "| Compare | [v1.0.0...v1.1.0](https://github.com/owner/repo/compare/v1.0.0...v1.1.0) |\n")
+	assert.Contains(t, summary, "- fix: third | fourth (`"+fix.String()[:7]+"`)\n")
+	assert.Contains(t, summary, "- feat: second (`")
+	assert.NotContains(t, summary, "Dry run")
+	assert.NotContains(t, summary, "Floating tags")
+
+	plans[0].Floating = []string{"v1", "v1.1"}
+	assert.Contains(t, renderSummary(cfg, plans), "| Floating tags | `v1`, `v1.1` |\n")
+
+	// the first version links the tree of tag, and no link without repository
+	r = newTestRepo(t, "main")
+	r.commit("feat: first")
+	cfg.DryRun = true
+	plans, err = planTags(r.Repository, cfg)
+	assert.NoError(t, err)
+
+	summary = renderSummary(cfg, plans)
+	assert.Contains(t, summary, "Dry run")
+	assert.Contains(t, summary, "| Previous tag | none |\n")
+	assert.Contains(t, summary, "](https://github.com/owner/repo/tree/")
+
+	cfg.GithubRepository = ""
+	assert.NotContains(t, renderSummary(cfg, plans), "| Compare |")
+
+	assert.Contains(t, renderSummary(cfg, nil), "Nothing to tag")
+}
+
+func TestWriteSummary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "summary")
+	assert.NoError(t, os.WriteFile(path, []byte("# Build\n"), 0644))
+	setEnv(t, "GITHUB_STEP_SUMMARY", path)
+
+	assert.NoError(t, writeSummary(testConfig(t, nil), nil))
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "# Build\n"+renderSummary(testConfig(t, nil), nil), string(b))
+}
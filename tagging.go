@@ -0,0 +1,279 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"golang.org/x/crypto/openpgp"
+)
+
+// tagAndPush plans, creates and pushes tags on HEAD, and returns the plans of tags pushed,
+// along with those of version tags HEAD already has, which are marked by Existing.
+// With fetch, remote tags are fetched and shallow history is deepened to the previous tags first.
+//
+// Another run may push the same version first, e.g. when two merges land seconds apart.
+// Tags are never overwritten on the remote, so the push is rejected. Then local tags and the changelog
+// commit are rolled back, and versions are recomputed from the remote tags, up to push_attempts times.
+func tagAndPush(r *git.Repository, cfg *Config, signKey *openpgp.Entity, outputs *Outputs) ([]*plannedTag, error) {
+	base, err := r.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Fetch {
+		logger.Group("Discover tags")
+		Info("Fetch tags from %s", cfg.Remote)
+		err := fetchTags(r, cfg)
+		logger.EndGroup()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tags: %w", err)
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		logger.Group("Compute version")
+		all, err := computePlans(r, cfg)
+		logger.EndGroup()
+		if err != nil {
+			return nil, err
+		}
+
+		plans := setExistingTags(all, outputs)
+
+		logger.Group("Create tag")
+		refSpecs, newTags, err := createTags(r, cfg, plans, signKey, outputs)
+		var floatingRefSpecs []config.RefSpec
+		var floating []string
+		if err == nil {
+			floatingRefSpecs, floating, err = moveFloatingTags(r, cfg, plans)
+		}
+		logger.EndGroup()
+		if err != nil {
+			return nil, err
+		}
+		outputs.Set("new_tags", strings.Join(newTags, " "))
+		outputs.Set("floating_tags", strings.Join(floating, " "))
+
+		if len(plans) == 0 {
+			Info("No components changed or HEAD is already tagged, nothing to tag")
+			return all, nil
+		}
+
+		if cfg.DryRun {
+			Notice("Dry run, next version: %s", strings.Join(newTags, ", "))
+			return all, nil
+		}
+
+		logger.Group("Push")
+		retry, err := pushTags(r, cfg, base.Hash(), append(refSpecs, floatingRefSpecs...), newTags, floating, attempt)
+		logger.EndGroup()
+		if err != nil {
+			return nil, err
+		}
+
+		if !retry {
+			Notice("Success to bump version: %s", strings.Join(newTags, ", "))
+			if len(floating) > 0 {
+				Notice("Floating tags moved: %s", strings.Join(floating, ", "))
+			}
+			return all, nil
+		}
+	}
+}
+
+// computePlans plans tags, deepening shallow history to the previous tags with fetch.
+func computePlans(r *git.Repository, cfg *Config) ([]*plannedTag, error) {
+	plans, err := planTags(r, cfg)
+	if err != nil || !cfg.Fetch {
+		return plans, err
+	}
+
+	deepened, err := ensureHistory(r, cfg, plans)
+	if err != nil {
+		return nil, err
+	}
+	if deepened {
+		return planTags(r, cfg)
+	}
+	return plans, nil
+}
+
+// pushTags pushes refSpecs of newTags and floating tags, and rolls them back if rejected.
+// With floating tags, all refs are pushed atomically, so they never move without the new tags.
+// It tells whether to retry with recomputed versions, as another run pushed the same tags first.
+func pushTags(r *git.Repository, cfg *Config, base plumbing.Hash, refSpecs []config.RefSpec, newTags, floating []string, attempt int) (bool, error) {
+	Info("Push %s to %s", strings.Join(append(append([]string{}, newTags...), floating...), ", "), cfg.Remote)
+	var err error
+	if len(floating) > 0 {
+		err = pushAtomic(r, cfg, refSpecs)
+	} else {
+		err = push(r, cfg, refSpecs)
+	}
+	if err == nil {
+		return false, nil
+	}
+
+	var authErr *AuthError
+	if errors.As(err, &authErr) {
+		return false, err
+	}
+
+	conflict, rollbackErr := rollbackTags(r, cfg, base, newTags, floating)
+	if rollbackErr != nil {
+		return false, fmt.Errorf("failed to roll back tags rejected by %s: %w", err, rollbackErr)
+	}
+	if conflict == "" {
+		return false, &PushRejectedError{Remote: cfg.Remote, Tags: newTags, Err: err}
+	}
+
+	// recomputed versions would be below the tag, e.g. when a later commit has been tagged first
+	ok, historyErr := inHistory(r, conflict, base)
+	if historyErr != nil {
+		return false, historyErr
+	}
+	if !ok {
+		return false, &TagExistsError{Tag: conflict, Remote: cfg.Remote, Reason: "pushed by another run on a commit which is not in the history of HEAD"}
+	}
+	if attempt >= cfg.PushAttempts {
+		return false, &TagExistsError{Tag: conflict, Remote: cfg.Remote, Reason: fmt.Sprintf("pushed by other runs for all of %d attempts", attempt)}
+	}
+
+	Warning("Tags are pushed by another run: %s, retry with recomputed versions (%d/%d)", err, attempt+1, cfg.PushAttempts)
+	return true, nil
+}
+
+// setExistingTags sets outputs of the version tags HEAD already has, and returns the plans of new tags.
+func setExistingTags(plans []*plannedTag, outputs *Outputs) []*plannedTag {
+	var pending []*plannedTag
+	for _, p := range plans {
+		if p.Existing == nil {
+			pending = append(pending, p)
+			continue
+		}
+
+		Notice("HEAD is already tagged %s", p.Existing.String())
+		if p.Component == nil {
+			outputs.SetTagged(p.Existing, p.Tagged)
+		} else {
+			outputs.SetComponentTagged(p.Component.Name, p.Existing, p.Tagged)
+		}
+	}
+	return pending
+}
+
+// createTags commits the changelog if enabled, and creates the planned tags on HEAD.
+// It returns refspecs to push them, which never overwrite the remote tags, and names of new tags.
+// Nothing is created in dry run.
+func createTags(r *git.Repository, cfg *Config, plans []*plannedTag, signKey *openpgp.Entity, outputs *Outputs) ([]config.RefSpec, []string, error) {
+	var refSpecs []config.RefSpec
+	var newTags []string
+
+	if len(plans) == 0 {
+		return nil, nil, nil
+	}
+
+	if cfg.Changelog {
+		if cfg.DryRun {
+			Info("Dry run, %s is not committed:", cfg.ChangelogFile)
+			Verbatim(strings.Join(changelogSections(plans, cfg.now()), "\n"))
+		} else {
+			refSpec, err := commitChangelog(r, cfg, plans, signKey)
+			if err != nil {
+				return nil, nil, err
+			}
+			refSpecs = append(refSpecs, refSpec)
+		}
+	}
+
+	c, err := getHeadCommit(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, p := range plans {
+		version := p.Next.Version
+
+		// Summery commit messages to write description of tag
+		opts := &git.CreateTagOptions{
+			Tagger: &object.Signature{
+				Name:  cfg.TaggerName,
+				Email: cfg.TaggerEmail,
+				When:  cfg.now(),
+			},
+			Message: p.Message,
+			SignKey: signKey,
+		}
+
+		if err := opts.Validate(r, c.Hash); err != nil {
+			return nil, nil, err
+		}
+
+		refSpec := fmt.Sprintf("refs/tags/%s:refs/tags/%s", version.String(), version.String())
+		refSpecs = append(refSpecs, config.RefSpec(refSpec))
+		newTags = append(newTags, version.String())
+
+		if p.Component == nil {
+			outputs.SetVersion(p.Next, c.Hash)
+		} else {
+			outputs.SetComponentVersion(p.Component.Name, p.Next, c.Hash)
+		}
+
+		if cfg.DryRun {
+			reportDryRun(version, c, p.Message, refSpec)
+			continue
+		}
+
+		_, err := r.CreateTag(version.String(), c.Hash, opts)
+		if err == git.ErrTagExists {
+			return nil, nil, &TagExistsError{Tag: version.String(), Reason: "but not on HEAD"}
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	Debug("Latest commit: %s", c.Hash.String())
+	return refSpecs, newTags, nil
+}
+
+// rollbackTags deletes tags created by the rejected push, resets HEAD to base to drop the changelog commit,
+// and fetches the remote tags, which restores the floating tags. It returns the first of tags which has been
+// pushed by another run, so versions should be recomputed, or empty string if the push was rejected for another reason.
+func rollbackTags(r *git.Repository, cfg *Config, base plumbing.Hash, tags, floating []string) (string, error) {
+	for _, tag := range append(append([]string{}, tags...), floating...) {
+		if err := r.DeleteTag(tag); err != nil && err != git.ErrTagNotFound {
+			return "", err
+		}
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return "", err
+	}
+
+	if head.Hash() != base {
+		if err := resetChangelogCommit(r, cfg, base); err != nil {
+			return "", err
+		}
+	}
+
+	if err := fetchTags(r, cfg); err != nil {
+		return "", err
+	}
+
+	for _, tag := range tags {
+		_, err := r.Tag(tag)
+		if err == nil {
+			return tag, nil
+		}
+		if err != git.ErrTagNotFound {
+			return "", err
+		}
+	}
+	return "", nil
+}
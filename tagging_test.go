@@ -0,0 +1,277 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestRemote returns a bare repository with main branch and tag v1.0.0 pushed from r.
+func newTestRemote(t *testing.T, r *testRepo) string {
+	dir := t.TempDir()
+	_, err := git.PlainInit(dir, true)
+	assert.NoError(t, err)
+
+	r.tag("v1.0.0", r.commit("chore: first"))
+	_, err = r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{dir}})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Push(&git.PushOptions{RefSpecs: []config.RefSpec{"refs/heads/main:refs/heads/main", "refs/tags/*:refs/tags/*"}}))
+
+	return dir
+}
+
+// pushTagByAnotherRun pushes a new commit of r to main, which another run tags with name in its clone.
+// Only the tag is pushed, so r doesn't have it until fetched.
+func pushTagByAnotherRun(t *testing.T, r *testRepo, remoteDir, name string) plumbing.Hash {
+	h := r.commit("fix: another run")
+	assert.NoError(t, r.Push(&git.PushOptions{RefSpecs: []config.RefSpec{"refs/heads/main:refs/heads/main"}}))
+
+	clone, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{URL: remoteDir, ReferenceName: plumbing.NewBranchReferenceName("main")})
+	assert.NoError(t, err)
+
+	other := &testRepo{t: t, Repository: clone}
+	other.tag(name, h)
+	assert.NoError(t, other.Push(&git.PushOptions{RefSpecs: []config.RefSpec{config.RefSpec("refs/tags/" + name + ":refs/tags/" + name)}}))
+
+	return h
+}
+
+func remoteTagCommit(t *testing.T, remoteDir, name string) plumbing.Hash {
+	remote, err := git.PlainOpen(remoteDir)
+	assert.NoError(t, err)
+
+	ref, err := remote.Tag(name)
+	if !assert.NoError(t, err) {
+		return plumbing.ZeroHash
+	}
+
+	tag, err := remote.TagObject(ref.Hash())
+	if err != nil {
+		return ref.Hash()
+	}
+	return tag.Target
+}
+
+func TestTagAndPush_Retry(t *testing.T) {
+	r := newTestRepo(t, "main")
+	remoteDir := newTestRemote(t, r)
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.Changelog = true
+		cfg.Fetch = false // another run pushes after fetch
+	})
+
+	other := pushTagByAnotherRun(t, r, remoteDir, "v1.0.1")
+	h := r.commitFile("main.go", "fix: mine")
+
+	// changes of earlier steps in the workspace
+	w, err := r.Worktree()
+	assert.NoError(t, err)
+	mainPath := filepath.Join(w.Filesystem.Root(), "main.go")
+	assert.NoError(t, os.WriteFile(mainPath, []byte("built"), 0644))
+
+	outputs := NewOutputs()
+	plans, err := tagAndPush(r.Repository, cfg, nil, outputs)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Equal(t, "v1.0.2", plans[0].Next.Version.String())
+	}
+	assert.Equal(t, "v1.0.2", outputs.Get("new_tag"))
+	assert.Equal(t, "v1.0.2", outputs.Get("new_tags"))
+
+	// the tag of another run is kept
+	assert.Equal(t, other, remoteTagCommit(t, remoteDir, "v1.0.1"))
+
+	// the changelog of the rejected version is dropped
+	head, err := r.Head()
+	assert.NoError(t, err)
+	c, err := r.CommitObject(head.Hash())
+	assert.NoError(t, err)
+	assert.Equal(t, "chore(release): v1.0.2", c.Message)
+	assert.Equal(t, h, c.ParentHashes[0])
+	assert.Equal(t, head.Hash(), remoteTagCommit(t, remoteDir, "v1.0.2"))
+
+	// only the changelog is rolled back, once
+	b, err := os.ReadFile(mainPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "built", string(b))
+	b, err = os.ReadFile(filepath.Join(w.Filesystem.Root(), cfg.ChangelogFile))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(b), "## v1.0.1")
+	assert.Contains(t, string(b), "## v1.0.2")
+	status, err := w.Status()
+	assert.NoError(t, err)
+	_, changed := status[cfg.ChangelogFile]
+	assert.False(t, changed)
+	assert.Equal(t, git.Modified, status.File("main.go").Worktree)
+}
+
+func TestTagAndPush_Attempts(t *testing.T) {
+	r := newTestRepo(t, "main")
+	remoteDir := newTestRemote(t, r)
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.PushAttempts = 1
+		cfg.Fetch = false
+	})
+
+	pushTagByAnotherRun(t, r, remoteDir, "v1.0.1")
+	r.commit("fix: mine")
+
+	_, err := tagAndPush(r.Repository, cfg, nil, NewOutputs())
+	if assert.IsType(t, &TagExistsError{}, err) {
+		assert.Equal(t, "v1.0.1", err.(*TagExistsError).Tag)
+		assert.Equal(t, ExitTagExists, handleError(io.Discard, err))
+	}
+}
+
+func TestTagAndPush_TaggedDescendant(t *testing.T) {
+	r := newTestRepo(t, "main")
+	r.commit("feat: ancient")
+	remoteDir := newTestRemote(t, r)
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.Fetch = false
+	})
+
+	// another run tags a later commit first
+	r.commit("fix: mine")
+	assert.NoError(t, r.Push(&git.PushOptions{RefSpecs: []config.RefSpec{"refs/heads/main:refs/heads/main"}}))
+	clone, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{URL: remoteDir, ReferenceName: plumbing.NewBranchReferenceName("main")})
+	assert.NoError(t, err)
+	other := &testRepo{t: t, Repository: clone}
+	other.tag("v1.0.1", other.commit("fix: later"))
+	assert.NoError(t, other.Push(&git.PushOptions{RefSpecs: []config.RefSpec{"refs/tags/v1.0.1:refs/tags/v1.0.1"}}))
+
+	_, err = tagAndPush(r.Repository, cfg, nil, NewOutputs())
+	if assert.IsType(t, &TagExistsError{}, err) {
+		assert.Equal(t, "v1.0.1", err.(*TagExistsError).Tag)
+		assert.Contains(t, err.Error(), "not in the history of HEAD")
+	}
+
+	// the tag of the later commit is no previous version, so commits released in v1.0.0 don't count again
+	next := nextVersion(t, r, cfg)
+	assert.Equal(t, "v1.0.1", next.Version.String())
+	assert.Equal(t, "v1.0.0", previousTag(next.Previous))
+}
+
+func TestTagAndPush_DryRun(t *testing.T) {
+	r := newTestRepo(t, "main")
+	r.tag("v1.0.0", r.commit("feat: first"))
+	r.commit("feat: second")
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.DryRun = true
+		cfg.Fetch = false
+	})
+
+	outputs := NewOutputs()
+	plans, err := tagAndPush(r.Repository, cfg, nil, outputs)
+	assert.NoError(t, err)
+	assert.Len(t, plans, 1)
+	assert.Equal(t, "v1.1.0", outputs.Get("new_tag"))
+
+	_, err = r.Tag("v1.1.0")
+	assert.Equal(t, git.ErrTagNotFound, err)
+}
+
+func TestTagAndPush_FloatingTags(t *testing.T) {
+	r := newTestRepo(t, "main")
+	remoteDir := newTestRemote(t, r)
+	first, err := r.Head()
+	assert.NoError(t, err)
+	r.tag("v1", first.Hash())
+	assert.NoError(t, r.Push(&git.PushOptions{RefSpecs: []config.RefSpec{"refs/tags/v1:refs/tags/v1"}}))
+
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.Changelog = true
+		cfg.FloatingTags = []string{"major", "minor"}
+	})
+
+	r.commitFile("main.go", "fix: mine")
+
+	outputs := NewOutputs()
+	plans, err := tagAndPush(r.Repository, cfg, nil, outputs)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Equal(t, []string{"v1", "v1.0"}, plans[0].Floating)
+	}
+	assert.Equal(t, "v1 v1.0", outputs.Get("floating_tags"))
+
+	head, err := r.Head()
+	assert.NoError(t, err)
+	assert.Equal(t, head.Hash(), remoteTagCommit(t, remoteDir, "v1.0.1"))
+	assert.Equal(t, head.Hash(), remoteTagCommit(t, remoteDir, "v1"))
+	assert.Equal(t, head.Hash(), remoteTagCommit(t, remoteDir, "v1.0"))
+}
+
+func TestTagAndPush_FloatingTagsRejected(t *testing.T) {
+	r := newTestRepo(t, "main")
+	remoteDir := newTestRemote(t, r)
+	first, err := r.Head()
+	assert.NoError(t, err)
+	r.tag("v1", first.Hash())
+	assert.NoError(t, r.Push(&git.PushOptions{RefSpecs: []config.RefSpec{"refs/tags/v1:refs/tags/v1"}}))
+
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.PushAttempts = 1
+		cfg.Fetch = false
+		cfg.FloatingTags = []string{"major"}
+	})
+
+	pushTagByAnotherRun(t, r, remoteDir, "v1.0.1")
+	r.commit("fix: mine")
+
+	_, err = tagAndPush(r.Repository, cfg, nil, NewOutputs())
+	assert.IsType(t, &TagExistsError{}, err)
+
+	// the floating tag doesn't move without the version tag, and is restored locally
+	assert.Equal(t, first.Hash(), remoteTagCommit(t, remoteDir, "v1"))
+	ref, err := r.Tag("v1")
+	assert.NoError(t, err)
+	assert.Equal(t, first.Hash(), ref.Hash())
+}
+
+func TestTagAndPush_RerunWithChangelog(t *testing.T) {
+	r := newTestRepo(t, "main")
+	remoteDir := newTestRemote(t, r)
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.Changelog = true
+	})
+
+	base := r.commitFile("main.go", "fix: mine")
+	_, err := tagAndPush(r.Repository, cfg, nil, NewOutputs())
+	assert.NoError(t, err)
+	released := remoteTagCommit(t, remoteDir, "v1.0.1")
+
+	// re-run checks out the same commit, which has the tag on its changelog commit
+	clone, err := git.PlainClone(t.TempDir(), false, &git.CloneOptions{URL: remoteDir, ReferenceName: plumbing.NewBranchReferenceName("main")})
+	assert.NoError(t, err)
+	w, err := clone.Worktree()
+	assert.NoError(t, err)
+	assert.NoError(t, w.Reset(&git.ResetOptions{Commit: base, Mode: git.HardReset}))
+
+	outputs := NewOutputs()
+	plans, err := tagAndPush(clone, cfg, nil, outputs)
+	assert.NoError(t, err)
+	if assert.Len(t, plans, 1) {
+		assert.Equal(t, "v1.0.1", plans[0].Existing.String())
+		assert.Nil(t, plans[0].Next)
+	}
+	assert.Equal(t, "", outputs.Get("new_tag"))
+	assert.Equal(t, "v1.0.1", outputs.Get("tag"))
+	assert.Equal(t, released.String(), outputs.Get("commit"))
+
+	head, err := clone.Head()
+	assert.NoError(t, err)
+	assert.Equal(t, base, head.Hash())
+}
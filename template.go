@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TagTemplate is the form of version tag names: Prefix, the version, then Suffix, e.g. sdk/go/v1.2.3 or 1.2.3-linux.
+type TagTemplate struct {
+	Prefix string
+	Suffix string
+}
+
+// tagAffixRegex rejects characters which git doesn't allow in tag names.
+// See https://git-scm.com/docs/git-check-ref-format
+var tagAffixRegex = regexp.MustCompile("^[^\\s~^:?*\\[\\\\]*$")
+
+// ParseTagTemplate renders template of tag names, e.g. "{prefix}{version}{suffix}" or "sdk/go/{prefix}{version}",
+// with {prefix} and {suffix} replaced by prefix and suffix. {version} must be given once.
+func ParseTagTemplate(template, prefix, suffix string) (*TagTemplate, error) {
+	parts := strings.Split(template, "{version}")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("must have {version} once, got %s", template)
+	}
+
+	var rendered []string
+	for _, part := range parts {
+		var err error
+		part = placeholderRegex.ReplaceAllStringFunc(part, func(p string) string {
+			switch p {
+			case "{prefix}":
+				return prefix
+			case "{suffix}":
+				return suffix
+			default:
+				err = fmt.Errorf("unknown placeholder %s in %s, must be one of {prefix}, {version} and {suffix}", p, template)
+				return p
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !tagAffixRegex.MatchString(part) || strings.Contains(part, "..") {
+			return nil, fmt.Errorf("invalid characters for tag name in %s", part)
+		}
+		rendered = append(rendered, part)
+	}
+
+	return &TagTemplate{Prefix: rendered[0], Suffix: rendered[1]}, nil
+}
+
+func (t *TagTemplate) String() string {
+	return t.Prefix + "{version}" + t.Suffix
+}
+
+// trim returns name without prefix and suffix, or false if name isn't of the template.
+func (t *TagTemplate) trim(name string) (string, bool) {
+	if len(name) <= len(t.Prefix)+len(t.Suffix) || !strings.HasPrefix(name, t.Prefix) || !strings.HasSuffix(name, t.Suffix) {
+		return "", false
+	}
+	return name[len(t.Prefix) : len(name)-len(t.Suffix)], true
+}
+
+// Parse parses the name of version tag, which is the reverse of VersionTag.String for versions of the template.
+// The suffix is trimmed first, so it can't be taken as pre-release, e.g. 1.2.3-linux.
+func (t *TagTemplate) Parse(name string) (*VersionTag, error) {
+	body, ok := t.trim(name)
+	if !ok {
+		return nil, fmt.Errorf("tag not of template <%s>: <%s>", t.String(), name)
+	}
+
+	version, err := VersionFromString(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if version.Tag != "" {
+		return nil, fmt.Errorf("tag not of template <%s>: <%s>", t.String(), name)
+	}
+
+	version.Tag = t.Prefix
+	version.Suffix = t.Suffix
+
+	return version, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTagTemplate(t *testing.T) {
+	tmpl, err := ParseTagTemplate("{prefix}{version}{suffix}", "v", "")
+	assert.NoError(t, err)
+	assert.Equal(t, &TagTemplate{Prefix: "v"}, tmpl)
+
+	tmpl, err = ParseTagTemplate("sdk/go/{prefix}{version}-{suffix}", "v", "linux")
+	assert.NoError(t, err)
+	assert.Equal(t, &TagTemplate{Prefix: "sdk/go/v", Suffix: "-linux"}, tmpl)
+	assert.Equal(t, "sdk/go/v{version}-linux", tmpl.String())
+
+	for _, template := range []string{"{prefix}", "{version}{version}", "{prefix}{version}{build}", "release..{version}", "v{version}~1"} {
+		_, err := ParseTagTemplate(template, "v", "")
+		assert.Error(t, err, template)
+	}
+}
+
+func TestTagTemplate_Parse(t *testing.T) {
+	cases := map[string]*TagTemplate{
+		"v1.2.3":                 {Prefix: "v"},
+		"Release-1.2.3":          {Prefix: "Release-"},
+		"sdk/go/v1.2.3-rc.1":     {Prefix: "sdk/go/v"},
+		"1.2.3-linux":            {Suffix: "-linux"},
+		"v1.2.3-rc.1+b.5-linux":  {Prefix: "v", Suffix: "-linux"},
+		"app-2.0.0_amd64":        {Prefix: "app-", Suffix: "_amd64"},
+		"v1.2.3-rc.1-linux-next": {Prefix: "v", Suffix: "-linux-next"},
+	}
+	for name, tmpl := range cases {
+		v, err := tmpl.Parse(name)
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, name, v.String())
+			assert.Equal(t, tmpl.Prefix, v.Tag)
+			assert.Equal(t, tmpl.Suffix, v.Suffix)
+		}
+	}
+
+	v, err := (&TagTemplate{Suffix: "-linux"}).Parse("1.2.3-rc.1-linux")
+	assert.NoError(t, err)
+	assert.Equal(t, "rc.1", v.Pre)
+
+	for name, tmpl := range map[string]*TagTemplate{
+		"v1.2.3":        {Prefix: "v", Suffix: "-linux"},
+		"1.2.3-linux":   {Prefix: "v", Suffix: "-linux"},
+		"api/v1.2.3":    {Prefix: "v"},
+		"vx1.2.3":       {Prefix: "v"},
+		"v-linux":       {Prefix: "v", Suffix: "-linux"},
+		"v1.2.3-darwin": {Prefix: "v", Suffix: "-linux"},
+	} {
+		_, err := tmpl.Parse(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestVersionFromString_Prefix(t *testing.T) {
+	for _, str := range []string{"Release-1.2.3", "sdk/go/v1.2.3", "v10.0.0-rc.1", "1.2.3-4.5.6"} {
+		v, err := VersionFromString(str)
+		if assert.NoError(t, err, str) {
+			assert.Equal(t, str, v.String())
+		}
+	}
+
+	v, err := VersionFromString("sdk/go/v1.2.3")
+	assert.NoError(t, err)
+	assert.Equal(t, "sdk/go/v", v.Tag)
+
+	for _, str := range []string{"v01.2.3", "1.2.3.4", "v1.2"} {
+		_, err := VersionFromString(str)
+		assert.Error(t, err, str)
+	}
+}
+
+func TestTrunkStrategy_TagTemplate(t *testing.T) {
+	r := newTestRepo(t, "main")
+	r.tag("1.2.0-linux", r.commit("chore: first"))
+	r.tag("v1.9.0", r.commit("chore: other family"))
+	r.tag("1.3.0-darwin", r.commit("chore: another family"))
+	r.commit("feat: second")
+
+	cfg := testConfig(t, func(cfg *Config) {
+		cfg.Strategy = "trunk"
+		cfg.WithoutV = true
+		cfg.TagSuffix = "-linux"
+	})
+
+	next := nextVersion(t, r, cfg)
+	assert.Equal(t, "1.3.0-linux", next.Version.String())
+	assert.Equal(t, "1.2.0-linux", previousTag(next.Previous))
+}